@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+const (
+	bbModulePrefix = "bitbucket.org/"
+	bbAPIURL       = "https://api.bitbucket.org"
+
+	bitbucketPageSize = 100
+)
+
+// licenseFiles contains the common names of the license file, in the order of trying them.
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"} //nolint:gochecknoglobals
+
+// bitbucketProvider loads repository metadata using the Bitbucket Cloud API.
+//
+// Bitbucket has no stars, topics and license properties. The license is
+// detected from the license file (e.g. LICENSE) in the root of the main branch.
+type bitbucketProvider struct {
+	prefix  string
+	baseURL string
+	client  *http.Client
+}
+
+// newBitbucketProvider returns a provider for modules starting with prefix,
+// served by the Bitbucket Cloud API at baseURL.
+// The access token is optional, it is required only for private repositories.
+func newBitbucketProvider(prefix string, baseURL string, token string) *bitbucketProvider {
+	return &bitbucketProvider{
+		prefix:  prefix,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  newProviderHTTPClient("Bearer", token),
+	}
+}
+
+func (p *bitbucketProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+type bitbucketLink struct {
+	Name string `json:"name"`
+	Href string `json:"href"`
+}
+
+// bitbucketRepository contains the used properties of the Bitbucket Cloud API repository object.
+type bitbucketRepository struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Website     string    `json:"website"`
+	IsPrivate   bool      `json:"is_private"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	Workspace   struct {
+		Slug string `json:"slug"`
+	} `json:"workspace"`
+	MainBranch struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Links struct {
+		HTML  bitbucketLink   `json:"html"`
+		Clone []bitbucketLink `json:"clone"`
+	} `json:"links"`
+}
+
+type bitbucketTags struct {
+	Values []struct {
+		Name string `json:"name"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+func (p *bitbucketProvider) load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	module := query.module
+
+	slog.Debug("Loading Bitbucket repository", "module", module) //nolint:gosec // debug log
+
+	if err := bitbucketVersionSource(query); err != nil {
+		return nil, nil, err
+	}
+
+	owner, name := moduleToOwnerAndName(p.prefix, module)
+
+	base := p.baseURL + "/2.0/repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+
+	var rep bitbucketRepository
+
+	if err := getJSON(ctx, p.client, base, &rep); err != nil {
+		return nil, nil, err
+	}
+
+	repo := new(k6registry.Repository)
+
+	repo.Name = rep.Name
+	repo.Owner = rep.Workspace.Slug
+	repo.Description = rep.Description
+	repo.URL = rep.Links.HTML.Href
+
+	repo.Homepage = rep.Website
+	if len(repo.Homepage) == 0 {
+		repo.Homepage = repo.URL
+	}
+
+	repo.Public = !rep.IsPrivate
+	repo.CloneURL = httpsCloneURL(rep.Links.Clone)
+
+	if !rep.UpdatedOn.IsZero() {
+		repo.Timestamp = float64(rep.UpdatedOn.Unix())
+	}
+
+	if len(rep.MainBranch.Name) > 0 {
+		lic, err := loadLicense(ctx, p.client, base+"/src/"+escapeBranch(rep.MainBranch.Name))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		repo.License = lic
+	}
+
+	if query.unchanged(repo) {
+		return repo, nil, nil
+	}
+
+	var tags []string
+
+	next := base + "/refs/tags?pagelen=" + strconv.Itoa(bitbucketPageSize)
+
+	for len(next) > 0 {
+		var page bitbucketTags
+
+		if err := getJSON(ctx, p.client, next, &page); err != nil {
+			return nil, nil, err
+		}
+
+		for _, tag := range page.Values {
+			tags = append(tags, tag.Name)
+		}
+
+		next = page.Next
+	}
+
+	return repo, tags, nil
+}
+
+// bitbucketServerProvider loads repository metadata using the Bitbucket Server (Data Center) API.
+//
+// The module path is mapped to the project key and the repository slug
+// (for example git.example.com/PROJ/xk6-foo). Bitbucket Server has no
+// repository modification time, the time of the latest commit is used instead.
+type bitbucketServerProvider struct {
+	prefix  string
+	baseURL string
+	client  *http.Client
+}
+
+// newBitbucketServerProvider returns a provider for modules starting with prefix,
+// served by the Bitbucket Server instance at baseURL.
+// The HTTP access token is optional, it is required only for private repositories.
+func newBitbucketServerProvider(prefix string, baseURL string, token string) *bitbucketServerProvider {
+	return &bitbucketServerProvider{
+		prefix:  prefix,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  newProviderHTTPClient("Bearer", token),
+	}
+}
+
+func (p *bitbucketServerProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+// bitbucketServerRepository contains the used properties of the Bitbucket Server API repository object.
+type bitbucketServerRepository struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Public      bool   `json:"public"`
+	Archived    bool   `json:"archived"`
+	Project     struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Self  []bitbucketLink `json:"self"`
+		Clone []bitbucketLink `json:"clone"`
+	} `json:"links"`
+}
+
+type bitbucketServerTags struct {
+	Values []struct {
+		DisplayID string `json:"displayId"`
+	} `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+type bitbucketServerCommits struct {
+	Values []struct {
+		CommitterTimestamp int64 `json:"committerTimestamp"`
+	} `json:"values"`
+}
+
+func (p *bitbucketServerProvider) load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	module := query.module
+
+	slog.Debug("Loading Bitbucket Server repository", "module", module) //nolint:gosec // debug log
+
+	if err := bitbucketVersionSource(query); err != nil {
+		return nil, nil, err
+	}
+
+	project, slug := moduleToOwnerAndName(p.prefix, module)
+
+	base := p.baseURL + "/rest/api/1.0/projects/" + url.PathEscape(project) + "/repos/" + url.PathEscape(slug)
+
+	var rep bitbucketServerRepository
+
+	if err := getJSON(ctx, p.client, base, &rep); err != nil {
+		return nil, nil, err
+	}
+
+	repo := new(k6registry.Repository)
+
+	repo.Name = rep.Name
+	repo.Owner = rep.Project.Key
+	repo.Description = rep.Description
+
+	if len(rep.Links.Self) > 0 {
+		repo.URL = strings.TrimSuffix(rep.Links.Self[0].Href, "/browse")
+	}
+
+	repo.Homepage = repo.URL
+	repo.Public = rep.Public
+	repo.Archived = rep.Archived
+	repo.CloneURL = httpsCloneURL(rep.Links.Clone)
+
+	var commits bitbucketServerCommits
+
+	if err := getJSON(ctx, p.client, base+"/commits?limit=1", &commits); err != nil {
+		return nil, nil, err
+	}
+
+	if len(commits.Values) > 0 {
+		repo.Timestamp = float64(time.UnixMilli(commits.Values[0].CommitterTimestamp).Unix())
+	}
+
+	lic, err := loadLicense(ctx, p.client, base+"/raw")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo.License = lic
+
+	// The timestamp is the time of the last commit, which does not change when a tag is pushed
+	// on an older commit, so the tags are always listed.
+	var tags []string
+
+	for start := 0; ; {
+		var page bitbucketServerTags
+
+		query := "?limit=" + strconv.Itoa(bitbucketPageSize) + "&start=" + strconv.Itoa(start)
+
+		if err := getJSON(ctx, p.client, base+"/tags"+query, &page); err != nil {
+			return nil, nil, err
+		}
+
+		for _, tag := range page.Values {
+			tags = append(tags, tag.DisplayID)
+		}
+
+		if page.IsLastPage || len(page.Values) == 0 {
+			break
+		}
+
+		start = page.NextPageStart
+	}
+
+	return repo, tags, nil
+}
+
+// bitbucketVersionSource checks the version source of the query, Bitbucket has no releases.
+// The tags are used instead of the releases requested by the global default.
+func bitbucketVersionSource(query repositoryQuery) error {
+	if query.versionSource == k6registry.VersionSourceReleases && !query.defaultSource {
+		return fmt.Errorf("%w: %s: Bitbucket has no releases", errUnsupportedVersionSource, query.module)
+	}
+
+	return nil
+}
+
+// httpsCloneURL returns the HTTP(S) clone URL from the clone links.
+func httpsCloneURL(links []bitbucketLink) string {
+	for _, link := range links {
+		if link.Name == "https" || link.Name == "http" {
+			return link.Href
+		}
+	}
+
+	return ""
+}
+
+// loadLicense returns the SPDX ID of the first existing license file in the directory at dirURL.
+// A missing or unrecognized license file results in an empty ID.
+func loadLicense(ctx context.Context, client *http.Client, dirURL string) (string, error) {
+	for _, name := range licenseFiles {
+		text, err := getBody(ctx, client, dirURL+"/"+name, "text/plain")
+		if err != nil {
+			if errors.Is(err, errNotFound) {
+				continue
+			}
+
+			return "", err
+		}
+
+		return detectLicense(text), nil
+	}
+
+	return "", nil
+}
+
+// escapeBranch escapes the branch name for use in URL paths.
+// The slashes are kept, the API resolves the branch name from the path segments.
+func escapeBranch(branch string) string {
+	segments := strings.Split(branch, "/")
+
+	for idx := range segments {
+		segments[idx] = url.PathEscape(segments[idx])
+	}
+
+	return strings.Join(segments, "/")
+}
+
+var spdxIdentifierRE = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.+-]+)`)
+
+// licenseTitles contains the title and version lines of the most common licenses.
+var licenseTitles = []struct { //nolint:gochecknoglobals
+	title   string
+	version string
+	id      string
+}{
+	{"GNU AFFERO GENERAL PUBLIC LICENSE", "Version 3", "AGPL-3.0"},
+	{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3", "LGPL-3.0"},
+	{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1", "LGPL-2.1"},
+	{"GNU GENERAL PUBLIC LICENSE", "Version 3", "GPL-3.0"},
+	{"GNU GENERAL PUBLIC LICENSE", "Version 2", "GPL-2.0"},
+	{"Apache License", "Version 2.0", "Apache-2.0"},
+	{"Mozilla Public License Version 2.0", "", "MPL-2.0"},
+	{"MIT License", "", "MIT"},
+	{"ISC License", "", "ISC"},
+	{"This is free and unencumbered software released into the public domain", "", "Unlicense"},
+}
+
+// detectLicense returns the SPDX ID of the license text, or an empty string if not recognized.
+// The SPDX-License-Identifier tag takes precedence over the well-known license titles.
+func detectLicense(text []byte) string {
+	if match := spdxIdentifierRE.FindSubmatch(text); match != nil {
+		id, _ := findLicense(string(match[1]))
+
+		return id
+	}
+
+	// the title and the version are expected in the first two non-empty lines
+	const headerLines = 2
+
+	header := make([]string, 0, headerLines)
+
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+
+	for scanner.Scan() && len(header) < headerLines {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+			header = append(header, line)
+		}
+	}
+
+	if len(header) == 0 {
+		return ""
+	}
+
+	version := strings.Join(header[1:], " ")
+
+	for _, lic := range licenseTitles {
+		if strings.HasPrefix(header[0], lic.title) && strings.HasPrefix(version, lic.version) {
+			return lic.id
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,196 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func newTestBitbucketServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	var srv *httptest.Server
+
+	// Bitbucket Cloud
+	mux.HandleFunc("GET /2.0/repositories/szkiba/xk6-sqids", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{
+  "name": "xk6-sqids",
+  "description": "Generate short unique identifiers from numbers",
+  "is_private": false,
+  "updated_on": "2024-09-10T10:00:00.000000+00:00",
+  "workspace": {"slug": "szkiba"},
+  "mainbranch": {"name": "release/v1"},
+  "links": {
+    "html": {"href": "https://bitbucket.org/szkiba/xk6-sqids"},
+    "clone": [
+      {"name": "https", "href": "https://bitbucket.org/szkiba/xk6-sqids.git"},
+      {"name": "ssh", "href": "git@bitbucket.org:szkiba/xk6-sqids.git"}
+    ]
+  }
+}`)
+	})
+	mux.HandleFunc("GET /2.0/repositories/szkiba/xk6-sqids/src/release/v1/LICENSE.md", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "\n  MIT License\n\nCopyright (c) 2024 Iván Szkiba\n")
+	})
+	mux.HandleFunc("GET /2.0/repositories/szkiba/xk6-sqids/refs/tags", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = fmt.Fprint(w, `{"values": [{"name": "v0.2.0"}]}`)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"values": [{"name": "v0.1.0"}], "next": %q}`,
+			srv.URL+"/2.0/repositories/szkiba/xk6-sqids/refs/tags?page=2")
+	})
+
+	// Bitbucket Server
+	const serverRepo = "/rest/api/1.0/projects/K6/repos/xk6-foo"
+
+	mux.HandleFunc("GET "+serverRepo, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{
+  "name": "xk6-foo",
+  "description": "Foo extension",
+  "public": true,
+  "archived": true,
+  "project": {"key": "K6"},
+  "links": {
+    "self": [{"href": "https://git.example.com/projects/K6/repos/xk6-foo/browse"}],
+    "clone": [{"name": "http", "href": "https://git.example.com/scm/k6/xk6-foo.git"}]
+  }
+}`)
+	})
+	mux.HandleFunc("GET "+serverRepo+"/commits", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"values": [{"committerTimestamp": 1725962400123}]}`)
+	})
+	mux.HandleFunc("GET "+serverRepo+"/tags", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") == "1" {
+			_, _ = fmt.Fprint(w, `{"values": [{"displayId": "v1.1.0"}], "isLastPage": true}`)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `{"values": [{"displayId": "v1.0.0"}], "isLastPage": false, "nextPageStart": 1}`)
+	})
+
+	srv = httptest.NewServer(mux)
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestBitbucketProvider(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestBitbucketServer(t)
+	provider := newBitbucketProvider(bbModulePrefix, srv.URL, "")
+
+	repo, tags, err := provider.load(context.Background(), repositoryQuery{module: "bitbucket.org/szkiba/xk6-sqids"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Name != "xk6-sqids" || repo.Owner != "szkiba" || repo.License != "MIT" {
+		t.Errorf("unexpected repository metadata %+v", repo)
+	}
+
+	if repo.CloneURL != "https://bitbucket.org/szkiba/xk6-sqids.git" {
+		t.Errorf("got clone URL %q", repo.CloneURL)
+	}
+
+	if !repo.Public || repo.Timestamp != 1725962400 || repo.Homepage != repo.URL {
+		t.Errorf("unexpected repository metadata %+v", repo)
+	}
+
+	if want := []string{"v0.1.0", "v0.2.0"}; !slices.Equal(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestBitbucketServerProvider(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestBitbucketServer(t)
+	provider := newBitbucketServerProvider("git.example.com/", srv.URL, "")
+
+	repo, tags, err := provider.load(context.Background(), repositoryQuery{module: "git.example.com/K6/xk6-foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Name != "xk6-foo" || repo.Owner != "K6" {
+		t.Errorf("got owner/name %s/%s", repo.Owner, repo.Name)
+	}
+
+	if repo.URL != "https://git.example.com/projects/K6/repos/xk6-foo" {
+		t.Errorf("got URL %q", repo.URL)
+	}
+
+	if !repo.Archived || !repo.Public || repo.Timestamp != 1725962400 {
+		t.Errorf("unexpected repository metadata %+v", repo)
+	}
+
+	if repo.License != "" {
+		t.Errorf("got license %q for missing license file", repo.License)
+	}
+
+	if want := []string{"v1.0.0", "v1.1.0"}; !slices.Equal(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestBitbucketProvider_Releases(t *testing.T) {
+	t.Parallel()
+
+	query := repositoryQuery{module: "bitbucket.org/szkiba/xk6-sqids", versionSource: k6registry.VersionSourceReleases}
+
+	_, _, err := newBitbucketProvider(bbModulePrefix, "http://127.0.0.1:0", "").load(context.Background(), query)
+	if !errors.Is(err, errUnsupportedVersionSource) {
+		t.Fatalf("got error %v, want %v", err, errUnsupportedVersionSource)
+	}
+
+	// the releases of the global default fall back to tags
+	srv := newTestBitbucketServer(t)
+	query.defaultSource = true
+
+	_, tags, err := newBitbucketProvider(bbModulePrefix, srv.URL, "").load(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.1.0", "v0.2.0"}; !slices.Equal(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"MIT License\n\nCopyright (c) 2024", "MIT"},
+		{"                    GNU AFFERO GENERAL PUBLIC LICENSE\n                       Version 3, 19 November 2007", "AGPL-3.0"},
+		{"GNU GENERAL PUBLIC LICENSE\nVersion 2, June 1991", "GPL-2.0"},
+		{"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999", "LGPL-2.1"},
+		{"\n\n                                 Apache License\n                           Version 2.0, January 2004", "Apache-2.0"},
+		{"// SPDX-License-Identifier: bsd-3-clause\nCopyright", "BSD-3-Clause"},
+		{"Proprietary license, all rights reserved", ""},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		if got := detectLicense([]byte(c.text)); got != c.want {
+			t.Errorf("detectLicense(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
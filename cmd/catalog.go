@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/grafana/k6registry"
+)
+
+var errCatalogCollision = errors.New("catalog collision")
+
+// Kinds of the names indexed by the catalog.
+const (
+	catalogImports     = "imports"
+	catalogOutputs     = "outputs"
+	catalogSubcommands = "subcommands"
+)
+
+// catalog contains the extensions indexed by name, separately for each kind of name.
+// The same name can be used by different extensions as different kinds,
+// for example an output name and a subcommand name.
+type catalog map[string]map[string]k6registry.Extension
+
+// newCatalog returns the extensions indexed by their import paths, output names and subcommand names.
+// If the same name of the same kind is claimed by more than one extension, all collisions are reported in the error.
+func newCatalog(registry k6registry.Registry) (catalog, error) {
+	cat := catalog{
+		catalogImports:     make(map[string]k6registry.Extension),
+		catalogOutputs:     make(map[string]k6registry.Extension),
+		catalogSubcommands: make(map[string]k6registry.Extension),
+	}
+
+	claims := make(map[string][]string)
+
+	for _, ext := range registry {
+		for kind, names := range map[string][]string{
+			catalogImports:     ext.Imports,
+			catalogOutputs:     ext.Outputs,
+			catalogSubcommands: ext.Subcommands,
+		} {
+			for _, name := range names {
+				key := kind + " " + name
+
+				if !slices.Contains(claims[key], ext.Module) {
+					claims[key] = append(claims[key], ext.Module)
+				}
+
+				cat[kind][name] = ext
+			}
+		}
+	}
+
+	var collisions []string
+
+	for key, modules := range claims {
+		if len(modules) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s (%s)", key, strings.Join(modules, ", ")))
+		}
+	}
+
+	if len(collisions) > 0 {
+		slices.Sort(collisions)
+
+		return nil, fmt.Errorf("%w: %s", errCatalogCollision, strings.Join(collisions, "; "))
+	}
+
+	return cat, nil
+}
+
+// encodeCatalog writes the catalog of the registry as a JSON object with an object for each kind of name.
+func encodeCatalog(registry k6registry.Registry, output io.Writer, compact bool) error {
+	catalog, err := newCatalog(registry)
+	if err != nil {
+		return err
+	}
+
+	return newJSONEncoder(output, compact).Encode(catalog)
+}
@@ -0,0 +1,93 @@
+package cmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func TestNewCatalog(t *testing.T) {
+	t.Parallel()
+
+	registry := testFilterRegistry()
+
+	registry[2].Subcommands = []string{"foo"}
+	registry[2].Outputs = []string{"dashboard-lite", "foo"}
+
+	var buff bytes.Buffer
+
+	if err := encodeCatalog(registry, &buff, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var cat map[string]map[string]k6registry.Extension
+
+	if err := json.Unmarshal(buff.Bytes(), &cat); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]map[string]string{
+		"imports": {
+			"k6/x/sql": "github.com/grafana/xk6-sql",
+			"k6/x/foo": "github.com/example/xk6-foo",
+		},
+		"outputs": {
+			"dashboard":      "github.com/grafana/xk6-dashboard",
+			"dashboard-lite": "github.com/example/xk6-foo",
+			"foo":            "github.com/example/xk6-foo",
+		},
+		"subcommands": {
+			"foo": "github.com/example/xk6-foo",
+		},
+	}
+
+	if len(cat) != len(want) {
+		t.Fatalf("got %d catalog kinds, want %d", len(cat), len(want))
+	}
+
+	for kind, names := range want {
+		if len(cat[kind]) != len(names) {
+			t.Errorf("%s: got %d catalog entries, want %d", kind, len(cat[kind]), len(names))
+		}
+
+		for name, module := range names {
+			if cat[kind][name].Module != module {
+				t.Errorf("%s %s: got module %q, want %q", kind, name, cat[kind][name].Module, module)
+			}
+		}
+	}
+}
+
+func TestNewCatalog_Collision(t *testing.T) {
+	t.Parallel()
+
+	registry := testFilterRegistry()
+
+	registry[1].Outputs = []string{"dashboard", "sql"}
+	registry[2].Imports = []string{"k6/x/sql"}
+	registry[2].Outputs = []string{"sql"}
+	registry[2].Subcommands = []string{"dashboard"}
+
+	_, err := newCatalog(registry)
+	if !errors.Is(err, errCatalogCollision) {
+		t.Fatalf("got error %v, want %v", err, errCatalogCollision)
+	}
+
+	for _, name := range []string{
+		"imports k6/x/sql (github.com/grafana/xk6-sql, github.com/example/xk6-foo)",
+		"outputs sql (github.com/grafana/xk6-dashboard, github.com/example/xk6-foo)",
+	} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("collision %q not reported: %v", name, err)
+		}
+	}
+
+	// the same name of different kinds is not a collision
+	if strings.Contains(err.Error(), "subcommands") {
+		t.Errorf("unexpected collision: %v", err)
+	}
+}
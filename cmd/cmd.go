@@ -2,13 +2,22 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/grafana/k6registry"
 	"github.com/spf13/cobra"
@@ -20,10 +29,26 @@ var help string
 type options struct {
 	loadOptions
 
-	out     string
-	compact bool
-	quiet   bool
-	verbose bool
+	out      string
+	config   string
+	previous string
+	report   string
+	signKey  string
+	format   string
+	filter   string
+	fields   []string
+	offline  bool
+	envelope bool
+	watch    bool
+	interval time.Duration
+	signer   ed25519.PrivateKey
+	version  string
+	compact  bool
+	quiet    bool
+	verbose  bool
+
+	// SHA-256 digest of the source of the last generation.
+	sourceSHA256 string
 }
 
 // New creates new cobra command for exec command.
@@ -44,11 +69,13 @@ func New(levelVar *slog.LevelVar) (*cobra.Command, error) {
 				levelVar.Set(slog.LevelDebug)
 			}
 
+			opts.version = cmd.Root().Version
+
 			return run(cmd.Context(), args, opts)
 		},
 	}
 
-	root.AddCommand(schemaCmd())
+	root.AddCommand(schemaCmd(), diffCmd(), serveCmd(), verifyCmd())
 
 	ctx, err := newContext(context.TODO(), root.Root().Name())
 	if err != nil {
@@ -62,6 +89,15 @@ func New(levelVar *slog.LevelVar) (*cobra.Command, error) {
 	flags.SortFlags = false
 
 	flags.StringVarP(&opts.out, "out", "o", "", "write output to file instead of stdout")
+	flags.StringVar(&opts.config, "config", "", "read generator settings from config file")
+	flags.StringVar(
+		&opts.format,
+		"format",
+		defaultFormat,
+		"output format: "+strings.Join(formatNames(), ", "),
+	)
+	flags.StringVar(&opts.filter, "filter", "", "write only the extensions matching the filter expression")
+	flags.StringSliceVar(&opts.fields, "select", nil, "write only the selected extension properties (module is always written)")
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "no output, only validation")
 	flags.BoolVar(&opts.lint, "lint", false, "enable built-in linter")
 	flags.BoolVar(&opts.ignoreLintErrors, "ignore-lint-errors", false, "don't fail on lint errors")
@@ -71,7 +107,49 @@ func New(levelVar *slog.LevelVar) (*cobra.Command, error) {
 		nil,
 		"lint checks to apply. Check xk6 documentation for available options.",
 	)
-	flags.BoolVarP(&opts.compact, "compact", "c", false, "compact instead of pretty-printed output")
+	flags.StringVar(
+		(*string)(&opts.versionSource),
+		"version-source",
+		"",
+		"default source of versions: tags or releases (default depends on the repository manager, tags are used where there are no releases)",
+	)
+	flags.StringVar(
+		(*string)(&opts.prerelease),
+		"prerelease",
+		"",
+		"default prerelease version policy: include, exclude or latest-only (default include)",
+	)
+	flags.IntVar(
+		&opts.maxVersionsPerMajor,
+		"max-versions-per-major",
+		0,
+		"keep only the latest N versions of each major version (0 means all)",
+	)
+	flags.IntVar(&opts.lintParallel, "lint-parallel", 1, "number of versions of an extension to lint concurrently")
+	flags.IntVar(&opts.parallel, "parallel", 1, "number of extensions to process concurrently")
+	flags.StringVar(
+		&opts.previous,
+		"previous",
+		"",
+		"previous registry output, extensions with unchanged repository are reused from it",
+	)
+	flags.StringVar(&opts.report, "validation-report", "", "write the source validation problems as JSON to the file")
+	flags.BoolVar(&opts.offline, "offline", false, "generate the registry from cached data only, without network access")
+	flags.BoolVar(
+		&opts.envelope,
+		"envelope",
+		false,
+		"wrap the registry with generation metadata (generation time, generator version, source and extension digests)",
+	)
+	flags.StringVar(&opts.signKey, "sign-key", "", "sign the output files using the PEM encoded ed25519 private key file")
+	flags.BoolVar(&opts.watch, "watch", false, "regenerate the output when the source file changes, until interrupted")
+	flags.DurationVar(
+		&opts.interval,
+		"watch-interval",
+		defaultWatchInterval,
+		"regenerate the output periodically in watch mode to follow the repository changes",
+	)
+	flags.BoolVarP(&opts.compact, "compact", "c", false, "compact instead of pretty-printed JSON output")
 	flags.BoolVarP(&opts.verbose, "verbose", "v", false, "verbose logging")
 	root.MarkFlagsMutuallyExclusive("compact", "quiet")
 
@@ -95,6 +173,75 @@ func schemaCmd() *cobra.Command {
 }
 
 func run(ctx context.Context, args []string, opts *options) (result error) {
+	encoder, err := findEncoder(opts.format)
+	if err != nil {
+		return err
+	}
+
+	query, err := newOutputQuery(opts.filter, opts.fields)
+	if err != nil {
+		return err
+	}
+
+	if opts.envelope && !slices.Contains(envelopeFormats, opts.format) {
+		return fmt.Errorf("%w: the envelope is not supported in %s format", errInvalidFormat, opts.format)
+	}
+
+	if len(opts.signKey) > 0 {
+		if len(opts.out) == 0 && !opts.quiet {
+			return errSignRequiresOutput
+		}
+
+		opts.signer, err = readPrivateKey(opts.signKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.offline {
+		ctx = withOffline(ctx)
+	}
+
+	ctx = withGitHubClient(ctx)
+
+	var outputs []*namedOutput
+
+	if len(opts.config) > 0 {
+		cfg, err := readConfig(opts.config)
+		if err != nil {
+			return err
+		}
+
+		ctx, err = withConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		outputs, err = cfg.outputs()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(opts.previous) > 0 {
+		previous, err := readPrevious(opts.previous)
+		if err != nil {
+			return err
+		}
+
+		opts.loadOptions.previous = previous
+	}
+
+	if opts.watch {
+		if len(args) == 0 || len(opts.out) == 0 {
+			return errWatchRequiresFiles
+		}
+
+		return watch(ctx, args[0], opts, func(registry k6registry.Registry, output io.Writer) error {
+			return emit(registry, output, outputs, query, encoder, opts)
+		})
+	}
+
 	input := os.Stdin //nolint:forbidigo // CLI tool
 
 	if len(args) > 0 {
@@ -113,56 +260,151 @@ func run(ctx context.Context, args []string, opts *options) (result error) {
 		input = file
 	}
 
-	output := os.Stdout //nolint:forbidigo // CLI tool
+	registry, err := generate(ctx, input, opts)
+	if err != nil {
+		return err
+	}
 
-	if len(opts.out) > 0 {
-		file, err := os.Create(opts.out) //nolint:forbidigo // CLI tool
-		if err != nil {
-			return err
-		}
+	if len(opts.out) == 0 {
+		return emit(registry, os.Stdout, outputs, query, encoder, opts) //nolint:forbidigo // CLI tool
+	}
 
-		defer func() {
-			err := file.Close()
-			if result == nil && err != nil {
-				result = err
-			}
-		}()
+	// the output file is replaced only after a successful generation
+	var buff bytes.Buffer
+
+	if err := emit(registry, &buff, outputs, query, encoder, opts); err != nil {
+		return err
+	}
+
+	if opts.quiet {
+		return nil
+	}
+
+	_, err = writeSigned(opts.out, buff.Bytes(), opts.signer)
+
+	return err
+}
 
-		output = file
+// generate loads the registry from the source and writes the validation report if requested.
+// The SHA-256 digest of the source is stored in opts.
+func generate(ctx context.Context, input io.Reader, opts *options) (k6registry.Registry, error) {
+	hash := sha256.New()
+
+	registry, err := load(ctx, io.TeeReader(input, hash), opts.loadOptions)
+
+	opts.sourceSHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if len(opts.report) > 0 {
+		if err := writeValidationReport(opts.report, err); err != nil {
+			return nil, err
+		}
 	}
 
-	registry, err := load(ctx, input, opts.loadOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// emit writes the configured outputs and the main output of the generated registry.
+// The main output is encoded before the configured output files are replaced,
+// so an encoding failure leaves every file unchanged.
+func emit(
+	registry k6registry.Registry,
+	output io.Writer,
+	outputs []*namedOutput,
+	query *outputQuery,
+	encoder registryEncoder,
+	opts *options,
+) error {
+	selected, err := query.apply(registry)
 	if err != nil {
 		return err
 	}
 
-	if err := postRun(registry, output, opts); err != nil {
+	var buff bytes.Buffer
+
+	if err := postRun(selected, &buff, encoder, opts); err != nil {
 		return err
 	}
 
-	return nil
+	if err := writeOutputs(registry, outputs, opts.signer); err != nil {
+		return err
+	}
+
+	_, err = output.Write(buff.Bytes())
+
+	return err
+}
+
+// readRegistry reads a generated registry from filename.
+// The registry is either a JSON array or a JSON object, the envelope of the registry.
+func readRegistry(filename string) (k6registry.Registry, error) {
+	data, err := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var envelope k6registry.Envelope
+
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		if envelope.Registry == nil {
+			return nil, fmt.Errorf("%w: %s: missing registry property in envelope", errInvalidRegistry, filename)
+		}
+
+		return envelope.Registry, nil
+	}
+
+	var registry k6registry.Registry
+
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return registry, nil
 }
 
-func postRun(registry k6registry.Registry, output io.Writer, opts *options) error {
-	if opts.quiet {
-		return nil
+// readPrevious reads the registry generated previously from filename and returns its extensions by module path.
+func readPrevious(filename string) (map[string]*k6registry.Extension, error) {
+	registry, err := readRegistry(filename)
+	if err != nil {
+		return nil, err
 	}
 
-	return writeOutput(registry, output, opts.compact)
+	return extensionsByModule(registry), nil
 }
 
-func writeOutput(registry k6registry.Registry, output io.Writer, compact bool) error {
-	encoder := json.NewEncoder(output)
+// extensionsByModule returns the extensions of the registry by module path.
+func extensionsByModule(registry k6registry.Registry) map[string]*k6registry.Extension {
+	extensions := make(map[string]*k6registry.Extension, len(registry))
 
-	if !compact {
-		encoder.SetIndent("", "  ")
+	for idx := range registry {
+		extensions[registry[idx].Module] = &registry[idx]
 	}
 
-	encoder.SetEscapeHTML(false)
+	return extensions
+}
 
-	var source any = registry
+func postRun(registry k6registry.Registry, output io.Writer, encoder registryEncoder, opts *options) error {
+	if opts.quiet {
+		return nil
+	}
+
+	if opts.envelope {
+		envelope, err := newEnvelope(registry, opts.sourceSHA256, opts.version, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return encodeEnvelope(envelope, opts.format, output, opts.compact)
+	}
 
-	return encoder.Encode(source)
+	return encoder(registry, output, opts.compact)
 }
 
 const (
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// conditionalTransport makes conditional requests using the validators (ETag, Last-Modified)
+// of the previously stored responses. A 304 Not Modified response is replaced with the stored
+// response, so unchanged resources are not transferred again and, for most APIs, do not count
+// against the rate limit.
+//
+// The responses are stored in the cache directory of the request context.
+// Requests without cache directory in their context are passed through unchanged.
+type conditionalTransport struct {
+	base http.RoundTripper
+}
+
+// newConditionalTransport returns a conditional transport using base to send the requests.
+// If base is nil, http.DefaultTransport will be used.
+func newConditionalTransport(base http.RoundTripper) *conditionalTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &conditionalTransport{base: base}
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	dir, err := responsesDir(req.Context())
+	if err != nil {
+		return t.base.RoundTrip(req)
+	}
+
+	filename := filepath.Join(dir, conditionalKey(req))
+
+	stored := readStoredResponse(filename, req)
+	if stored != nil {
+		req = req.Clone(req.Context())
+
+		if etag := stored.Header.Get("ETag"); len(etag) > 0 {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if modified := stored.Header.Get("Last-Modified"); len(modified) > 0 {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && stored != nil {
+		_ = resp.Body.Close()
+
+		slog.Debug("Not modified", "url", req.URL.Redacted())
+
+		return stored, nil
+	}
+
+	if resp.StatusCode != http.StatusOK ||
+		(len(resp.Header.Get("ETag")) == 0 && len(resp.Header.Get("Last-Modified")) == 0) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := storeResponse(filename, resp, body); err != nil {
+		slog.Debug("Failed to store response", "url", req.URL.Redacted(), "error", err)
+	}
+
+	return resp, nil
+}
+
+// conditionalKey returns the storage key of the request.
+// The credentials are part of the key, responses are never shared between credentials.
+func conditionalKey(req *http.Request) string {
+	hash := sha256.New()
+
+	parts := []string{
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get("Authorization"),
+		req.Header.Get("Private-Token"),
+	}
+
+	for _, part := range parts {
+		_, _ = hash.Write([]byte(part))
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// readStoredResponse returns the response stored in filename, or nil if it is missing or invalid.
+func readStoredResponse(filename string, req *http.Request) *http.Response {
+	data, err := os.ReadFile(filepath.Clean(filename)) //nolint:gosec,forbidigo // cache dir
+	if err != nil {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+
+	return resp
+}
+
+// storeResponse stores resp with the given body in filename.
+func storeResponse(filename string, resp *http.Response, body []byte) error {
+	stored := *resp
+
+	stored.Body = io.NopCloser(bytes.NewReader(body))
+	stored.ContentLength = int64(len(body))
+	stored.TransferEncoding = nil
+
+	var buff bytes.Buffer
+
+	if err := stored.Write(&buff); err != nil {
+		return err
+	}
+
+	// concurrent readers must not see a partially written response
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".response-*") //nolint:forbidigo // cache dir
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(buff.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename) //nolint:forbidigo // cache dir
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name()) //nolint:forbidigo // cache dir
+	}
+
+	return err
+}
@@ -0,0 +1,81 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditionalTransport(t *testing.T) {
+	t.Parallel()
+
+	const etag = `"v1"`
+
+	var full, notModified int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		full++
+
+		w.Header().Set("ETag", etag)
+		_, _ = io.WriteString(w, `{"name": "xk6-foo"}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: newConditionalTransport(nil)}
+	ctx := context.WithValue(context.Background(), cacheDirKey{}, t.TempDir())
+
+	for range 3 {
+		body, err := getBody(ctx, client, srv.URL+"/repos/xk6-foo", "application/json")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(body); got != `{"name": "xk6-foo"}` {
+			t.Fatalf("got body %q", got)
+		}
+	}
+
+	if full != 1 || notModified != 2 {
+		t.Fatalf("got %d full and %d not modified responses, want 1 and 2", full, notModified)
+	}
+}
+
+func TestConditionalTransport_NoCacheDir(t *testing.T) {
+	t.Parallel()
+
+	var conditional int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("If-None-Match")) > 0 {
+			conditional++
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, "{}")
+	}))
+
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: newConditionalTransport(nil)}
+
+	for range 2 {
+		if _, err := getBody(context.Background(), client, srv.URL, "application/json"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if conditional != 0 {
+		t.Fatalf("got %d conditional requests without cache dir, want 0", conditional)
+	}
+}
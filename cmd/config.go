@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errInvalidConfig = errors.New("invalid config")
+
+// Provider types usable in the hosts section of the config file.
+const (
+	providerGitHub          = "github"
+	providerGitLab          = "gitlab"
+	providerGitea           = "gitea"
+	providerForgejo         = "forgejo"
+	providerBitbucket       = "bitbucket"
+	providerBitbucketServer = "bitbucket-server"
+)
+
+// config contains the generator settings read from the config file.
+type config struct {
+	// Hosts maps module path prefixes to repository providers.
+	// Configured hosts take precedence over the built-in providers.
+	Hosts []hostConfig `yaml:"hosts"`
+
+	// Outputs are written from the generated registry in addition to the main output.
+	Outputs []outputConfig `yaml:"outputs"`
+}
+
+// hostConfig describes the repository manager serving modules with a given prefix.
+type hostConfig struct {
+	// Module path prefix, for example "git.example.com/".
+	Prefix string `yaml:"prefix"`
+
+	// Provider type, for example "gitea".
+	Type string `yaml:"type"`
+
+	// Base URL of the repository manager, for example "https://git.example.com".
+	URL string `yaml:"url"`
+
+	// Name of the environment variable containing the access token (optional).
+	TokenEnv string `yaml:"token_env"`
+}
+
+// outputConfig describes an output file derived from the generated registry.
+type outputConfig struct {
+	// Path of the output file, relative to the directory of the config file.
+	Path string `yaml:"path"`
+
+	// Filter expression of the extensions to write (optional).
+	Filter string `yaml:"filter"`
+
+	// Top level extension properties to write (optional).
+	Select []string `yaml:"select"`
+
+	// Output format, json by default.
+	Format string `yaml:"format"`
+
+	// Compact instead of pretty-printed output.
+	Compact bool `yaml:"compact"`
+}
+
+// token returns the access token from the configured environment variable.
+func (h *hostConfig) token() (string, error) {
+	if len(h.TokenEnv) == 0 {
+		return "", nil
+	}
+
+	token := os.Getenv(h.TokenEnv) //nolint:forbidigo // CLI tool
+	if len(token) == 0 {
+		return "", fmt.Errorf("%w: host %s: environment variable %s is empty", errMissingAuthToken, h.Prefix, h.TokenEnv)
+	}
+
+	return token, nil
+}
+
+// readConfig reads the config file from filename.
+func readConfig(filename string) (*config, error) {
+	data, err := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(config)
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalidConfig, filename, err)
+	}
+
+	// relative output paths are relative to the directory of the config file
+	for idx := range cfg.Outputs {
+		if path := cfg.Outputs[idx].Path; len(path) > 0 && !filepath.IsAbs(path) {
+			cfg.Outputs[idx].Path = filepath.Join(filepath.Dir(filename), path)
+		}
+	}
+
+	return cfg, nil
+}
+
+// providers returns the repository providers of the configured hosts.
+// The providers are created on first use, so the access tokens are only required
+// for the hosts actually used.
+func (c *config) providers() ([]repositoryProvider, error) {
+	providers := make([]repositoryProvider, 0, len(c.Hosts))
+
+	for _, host := range c.Hosts {
+		if len(host.Prefix) == 0 || len(host.URL) == 0 {
+			return nil, fmt.Errorf("%w: host requires prefix and url", errInvalidConfig)
+		}
+
+		var create func(token string) (repositoryProvider, error)
+
+		switch host.Type {
+		case providerGitHub:
+			create = func(token string) (repositoryProvider, error) {
+				client, err := newGitHubEnterpriseClient(host.URL, token)
+				if err != nil {
+					return nil, err
+				}
+
+				return newGitHubProvider(host.Prefix, client), nil
+			}
+		case providerGitLab:
+			create = func(token string) (repositoryProvider, error) {
+				return newGitLabProvider(host.Prefix, host.URL, token), nil
+			}
+		case providerGitea, providerForgejo:
+			create = func(token string) (repositoryProvider, error) {
+				return newGiteaProvider(host.Prefix, host.URL, token), nil
+			}
+		case providerBitbucket:
+			create = func(token string) (repositoryProvider, error) {
+				return newBitbucketProvider(host.Prefix, host.URL, token), nil
+			}
+		case providerBitbucketServer:
+			create = func(token string) (repositoryProvider, error) {
+				return newBitbucketServerProvider(host.Prefix, host.URL, token), nil
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown provider type %q for host %s", errInvalidConfig, host.Type, host.Prefix)
+		}
+
+		providers = append(providers, newLazyProvider(host.Prefix, func() (repositoryProvider, error) {
+			token, err := host.token()
+			if err != nil {
+				return nil, err
+			}
+
+			return create(token)
+		}))
+	}
+
+	return providers, nil
+}
+
+// withConfig returns a copy of ctx that uses the providers of the configured hosts
+// before the providers already present in ctx.
+func withConfig(ctx context.Context, cfg *config) (context.Context, error) {
+	providers, err := cfg.providers()
+	if err != nil {
+		return nil, err
+	}
+
+	builtin, err := contextProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return withProviders(ctx, append(providers, builtin...)), nil
+}
@@ -0,0 +1,150 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeFileT(t, dir, "config.yaml", `
+hosts:
+  - prefix: git.example.com/
+    type: forgejo
+    url: https://git.example.com
+`)
+
+	cfg, err := readConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := withConfig(withProviders(context.Background(), defaultProviders()), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := findProvider(ctx, "git.example.com/team/xk6-foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitea, ok := unwrapProvider(t, provider).(*giteaProvider)
+	if !ok {
+		t.Fatalf("got provider %T, want *giteaProvider", provider)
+	}
+
+	if gitea.baseURL != "https://git.example.com" {
+		t.Fatalf("got base URL %q", gitea.baseURL)
+	}
+
+	if _, err := findProvider(ctx, "github.com/grafana/xk6-sql"); err != nil {
+		t.Fatalf("expected built-in providers to remain available: %v", err)
+	}
+}
+
+func TestReadConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unknown field": "hosts:\n  - prefix: git.example.com/\n    kind: gitea\n",
+		"unknown type":  "hosts:\n  - prefix: git.example.com/\n    type: svn\n    url: https://git.example.com\n",
+		"missing url":   "hosts:\n  - prefix: git.example.com/\n    type: gitea\n",
+	}
+
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			writeFileT(t, dir, "config.yaml", content)
+
+			cfg, err := readConfig(filepath.Join(dir, "config.yaml"))
+			if err == nil {
+				_, err = cfg.providers()
+			}
+
+			if !errors.Is(err, errInvalidConfig) {
+				t.Fatalf("got error %v, want %v", err, errInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestReadConfig_TokenEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFileT(t, dir, "config.yaml", `
+hosts:
+  - prefix: gitlab.corp.example/
+    type: gitlab
+    url: https://gitlab.corp.example
+    token_env: K6REGISTRY_TEST_GITLAB_TOKEN
+  - prefix: github.corp.example/
+    type: github
+    url: https://github.corp.example
+    token_env: K6REGISTRY_TEST_GITHUB_TOKEN
+`)
+
+	cfg, err := readConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("K6REGISTRY_TEST_GITLAB_TOKEN", "")
+	t.Setenv("K6REGISTRY_TEST_GITHUB_TOKEN", "ghes-token")
+
+	providers, err := cfg.providers()
+	if err != nil {
+		t.Fatalf("unused hosts must not require tokens: %v", err)
+	}
+
+	_, _, err = providers[0].load(context.Background(), repositoryQuery{module: "gitlab.corp.example/team/xk6-foo"})
+	if !errors.Is(err, errMissingAuthToken) {
+		t.Fatalf("got error %v, want %v", err, errMissingAuthToken)
+	}
+
+	t.Setenv("K6REGISTRY_TEST_GITLAB_TOKEN", "gitlab-token")
+
+	providers, err = cfg.providers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitlab, ok := unwrapProvider(t, providers[0]).(*gitlabProvider)
+	if !ok || gitlab.token != "gitlab-token" || gitlab.baseURL != "https://gitlab.corp.example" {
+		t.Fatalf("unexpected provider %+v", providers[0])
+	}
+
+	github, ok := unwrapProvider(t, providers[1]).(*githubProvider)
+	if !ok || github.client == nil {
+		t.Fatalf("unexpected provider %+v", providers[1])
+	}
+
+	if got := github.client.BaseURL(); got != "https://github.corp.example/api/v3/" {
+		t.Fatalf("got GitHub API URL %q", got)
+	}
+}
+
+// unwrapProvider returns the provider created by a lazy provider.
+func unwrapProvider(t *testing.T, provider repositoryProvider) repositoryProvider {
+	t.Helper()
+
+	lazy, ok := provider.(*lazyProvider)
+	if !ok {
+		t.Fatalf("got provider %T, want *lazyProvider", provider)
+	}
+
+	created, err := lazy.create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
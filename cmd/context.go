@@ -4,15 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/cli/go-gh/v2/pkg/auth"
-	"github.com/cli/go-gh/v2/pkg/config"
+	ghconfig "github.com/cli/go-gh/v2/pkg/config"
 	"github.com/google/go-github/v88/github"
 )
 
@@ -21,25 +23,20 @@ type githubClientKey struct{}
 var errInvalidContext = errors.New("invalid context")
 
 // contextGitHubClient returns a *github.Client from context.
+// The client is created on first use, so the GitHub token is only required if the GitHub API is used.
 func contextGitHubClient(ctx context.Context) (*github.Client, error) {
 	value := ctx.Value(githubClientKey{})
 	if value != nil {
-		if client, ok := value.(*github.Client); ok {
-			return client, nil
+		if client, ok := value.(func() (*github.Client, error)); ok {
+			return client()
 		}
 	}
 
 	return nil, fmt.Errorf("%w: missing github.Client", errInvalidContext)
 }
 
-// newContext prepares GitHub CLI extension context with http.Client and github.Client values.
-// You can use ContextHTTPClient and ContextGitHubClient later to get client instances from the context.
+// newContext prepares the context with the cache directory and the built-in repository providers.
 func newContext(ctx context.Context, appname string) (context.Context, error) {
-	htc, err := newHTTPClient()
-	if err != nil {
-		return nil, err
-	}
-
 	cacheDir, err := xdg.CacheFile(appname)
 	if err != nil {
 		return nil, err
@@ -52,35 +49,73 @@ func newContext(ctx context.Context, appname string) (context.Context, error) {
 
 	ctx = context.WithValue(ctx, cacheDirKey{}, cacheDir)
 
-	client, err := github.NewClient(github.WithHTTPClient(htc))
-	if err != nil {
-		return nil, err
-	}
+	return withProviders(ctx, defaultProviders()), nil
+}
+
+// withGitHubClient returns a copy of ctx with a lazily created github.Client value.
+// You can use contextGitHubClient later to get the client instance from the context.
+//
+// In offline mode the client serves only the cached responses.
+func withGitHubClient(ctx context.Context) context.Context {
+	offline := contextOffline(ctx)
+
+	client := sync.OnceValues(func() (*github.Client, error) {
+		htc, err := newHTTPClient("", "", offline)
+		if err != nil {
+			return nil, err
+		}
+
+		return github.NewClient(github.WithHTTPClient(htc))
+	})
 
-	return context.WithValue(ctx, githubClientKey{}, client), nil
+	return context.WithValue(ctx, githubClientKey{}, client)
 }
 
-const cacheTTL = 2 * time.Hour
+const (
+	cacheTTL = 2 * time.Hour
+
+	// offlineCacheTTL makes the cached responses never expire.
+	offlineCacheTTL = time.Duration(math.MaxInt64)
+)
 
 var errMissingAuthToken = errors.New("missing authentication token")
 
-func newHTTPClient() (*http.Client, error) {
+// newHTTPClient returns a caching HTTP client for the GitHub API.
+// If host is empty, the default host of the GitHub CLI will be used.
+// If token is empty, the token of the GitHub CLI will be used for the host.
+// An offline client serves only the cached responses, regardless of their age.
+// The cached responses are keyed by the token too, so the same token is required.
+func newHTTPClient(host string, token string, offline bool) (*http.Client, error) {
 	var opts api.ClientOptions
 
-	opts.Host, _ = auth.DefaultHost()
+	opts.Host = host
+	if len(opts.Host) == 0 {
+		opts.Host, _ = auth.DefaultHost()
+	}
+
+	opts.AuthToken = token
+	if len(opts.AuthToken) == 0 {
+		opts.AuthToken, _ = auth.TokenForHost(opts.Host)
+	}
 
-	opts.AuthToken, _ = auth.TokenForHost(opts.Host)
 	if opts.AuthToken == "" {
 		return nil, fmt.Errorf("%w: host %s", errMissingAuthToken, opts.Host)
 	}
 
-	if cfg, _ := config.Read(nil); cfg != nil {
+	if cfg, _ := ghconfig.Read(nil); cfg != nil {
 		opts.UnixDomainSocket, _ = cfg.Get([]string{"http_unix_socket"})
 	}
 
 	opts.EnableCache = true
 	opts.CacheTTL = cacheTTL
 
+	if offline {
+		opts.Transport = offlineTransport{}
+		opts.CacheTTL = offlineCacheTTL
+	} else if len(opts.UnixDomainSocket) == 0 {
+		opts.Transport = newConditionalTransport(nil)
+	}
+
 	return api.NewHTTPClient(opts)
 }
 
@@ -118,3 +153,11 @@ func modulesDir(ctx context.Context) (string, error) {
 func checksDir(ctx context.Context) (string, error) {
 	return cacheSubDir(ctx, "checks")
 }
+
+func reposDir(ctx context.Context) (string, error) {
+	return cacheSubDir(ctx, "repos")
+}
+
+func responsesDir(ctx context.Context) (string, error) {
+	return cacheSubDir(ctx, "responses")
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/grafana/k6registry"
+	"github.com/spf13/cobra"
+)
+
+// Formats of the diff subcommand.
+const (
+	diffFormatMarkdown = "markdown"
+	diffFormatJSON     = "json"
+)
+
+func diffCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "diff [flags] old-registry new-registry",
+		Short: "Report the changes between two generated registries",
+		Long: `Report the changes between two generated registries.
+
+The added and removed extensions, the added and removed versions, the tier changes, the newly archived repositories and the compliance regressions are reported per module.
+
+The report is a Markdown summary suitable for pull request comments by default. The JSON report (--format json) is intended for automation.`,
+		Args: cobra.ExactArgs(2), //nolint:mnd // old and new
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != diffFormatMarkdown && format != diffFormatJSON {
+				return fmt.Errorf("%w: %s", errInvalidFormat, format)
+			}
+
+			from, err := readRegistry(args[0])
+			if err != nil {
+				return err
+			}
+
+			to, err := readRegistry(args[1])
+			if err != nil {
+				return err
+			}
+
+			diff := diffRegistries(from, to)
+
+			if format == diffFormatJSON {
+				return newJSONEncoder(cmd.OutOrStdout(), false).Encode(diff)
+			}
+
+			return diff.writeMarkdown(cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", diffFormatMarkdown, "report format: markdown or json")
+
+	return cmd
+}
+
+// registryDiff contains the changes between two registries.
+type registryDiff struct {
+	// Extensions present only in the new registry.
+	Added []addedExtension `json:"added"`
+
+	// Modules present only in the old registry.
+	Removed []string `json:"removed"`
+
+	// Changes of the extensions present in both registries.
+	Changed []extensionDiff `json:"changed"`
+}
+
+type addedExtension struct {
+	Module   string          `json:"module"`
+	Tier     k6registry.Tier `json:"tier,omitempty"`
+	Versions []string        `json:"versions,omitempty"`
+}
+
+// extensionDiff contains the changes of an extension.
+type extensionDiff struct {
+	Module string `json:"module"`
+
+	AddedVersions   []string `json:"added_versions,omitempty"`
+	RemovedVersions []string `json:"removed_versions,omitempty"`
+
+	Tier *tierChange `json:"tier,omitempty"`
+
+	// The repository has been archived since the old registry.
+	Archived bool `json:"archived,omitempty"`
+
+	ComplianceRegressions []complianceRegression `json:"compliance_regressions,omitempty"`
+}
+
+type tierChange struct {
+	From k6registry.Tier `json:"from"`
+	To   k6registry.Tier `json:"to"`
+}
+
+// complianceRegression contains the compliance issues of a version missing from the old registry.
+type complianceRegression struct {
+	Version string   `json:"version"`
+	Issues  []string `json:"issues"`
+}
+
+func (d *extensionDiff) empty() bool {
+	return len(d.AddedVersions) == 0 && len(d.RemovedVersions) == 0 &&
+		d.Tier == nil && !d.Archived && len(d.ComplianceRegressions) == 0
+}
+
+// empty reports whether there are no changes at all.
+func (d *registryDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffRegistries returns the changes from the old registry to the new one.
+// The extensions are reported in the order of the registry they are taken from.
+func diffRegistries(from k6registry.Registry, to k6registry.Registry) *registryDiff {
+	diff := &registryDiff{
+		Added:   []addedExtension{},
+		Removed: []string{},
+		Changed: []extensionDiff{},
+	}
+
+	old := make(map[string]*k6registry.Extension, len(from))
+
+	for idx := range from {
+		old[from[idx].Module] = &from[idx]
+	}
+
+	current := make(map[string]bool, len(to))
+
+	for idx := range to {
+		ext := &to[idx]
+
+		current[ext.Module] = true
+
+		prev, found := old[ext.Module]
+		if !found {
+			diff.Added = append(diff.Added, addedExtension{Module: ext.Module, Tier: ext.Tier, Versions: ext.Versions})
+
+			continue
+		}
+
+		if changes := diffExtensions(prev, ext); !changes.empty() {
+			diff.Changed = append(diff.Changed, *changes)
+		}
+	}
+
+	for idx := range from {
+		if !current[from[idx].Module] {
+			diff.Removed = append(diff.Removed, from[idx].Module)
+		}
+	}
+
+	return diff
+}
+
+// diffExtensions returns the changes of an extension.
+func diffExtensions(from *k6registry.Extension, to *k6registry.Extension) *extensionDiff {
+	diff := &extensionDiff{Module: to.Module}
+
+	diff.AddedVersions = missingFrom(to.Versions, from.Versions)
+	diff.RemovedVersions = missingFrom(from.Versions, to.Versions)
+
+	if from.Tier != to.Tier {
+		diff.Tier = &tierChange{From: from.Tier, To: to.Tier}
+	}
+
+	diff.Archived = (from.Repo == nil || !from.Repo.Archived) && to.Repo != nil && to.Repo.Archived
+
+	diff.ComplianceRegressions = complianceRegressions(from, to)
+
+	return diff
+}
+
+// complianceRegressions returns the compliance issues of the new registry missing from the old one.
+// The issues of a version are compared with the issues of the same version in the old registry,
+// or with the issues of the latest old version if the version is new.
+func complianceRegressions(from *k6registry.Extension, to *k6registry.Extension) []complianceRegression {
+	if len(from.Compliance) == 0 {
+		return nil
+	}
+
+	var latest k6registry.Compliance
+
+	if len(from.Versions) > 0 {
+		latest = from.Compliance[from.Versions[0]]
+	}
+
+	var regressions []complianceRegression
+
+	for _, version := range to.Versions {
+		comp, found := to.Compliance[version]
+		if !found {
+			continue
+		}
+
+		baseline, found := from.Compliance[version]
+		if !found {
+			baseline = latest
+		}
+
+		if issues := missingFrom(comp.Issues, baseline.Issues); len(issues) > 0 {
+			regressions = append(regressions, complianceRegression{Version: version, Issues: issues})
+		}
+	}
+
+	return regressions
+}
+
+// missingFrom returns the elements of values not present in other, in the original order.
+func missingFrom(values []string, other []string) []string {
+	var missing []string
+
+	for _, value := range values {
+		if !slices.Contains(other, value) {
+			missing = append(missing, value)
+		}
+	}
+
+	return missing
+}
+
+// writeMarkdown writes the Markdown summary of the changes to out.
+func (d *registryDiff) writeMarkdown(out io.Writer) error {
+	var buff strings.Builder
+
+	buff.WriteString("## Registry changes\n\n")
+
+	if d.empty() {
+		buff.WriteString("No changes.\n")
+	}
+
+	if len(d.Added) > 0 {
+		buff.WriteString("### Added extensions\n\n")
+
+		for _, ext := range d.Added {
+			fmt.Fprintf(&buff, "- `%s`", ext.Module)
+
+			if len(ext.Tier) > 0 {
+				fmt.Fprintf(&buff, " (%s)", ext.Tier)
+			}
+
+			if len(ext.Versions) > 0 {
+				fmt.Fprintf(&buff, ": %s", strings.Join(ext.Versions, ", "))
+			}
+
+			buff.WriteString("\n")
+		}
+
+		buff.WriteString("\n")
+	}
+
+	if len(d.Removed) > 0 {
+		buff.WriteString("### Removed extensions\n\n")
+
+		for _, module := range d.Removed {
+			fmt.Fprintf(&buff, "- `%s`\n", module)
+		}
+
+		buff.WriteString("\n")
+	}
+
+	if len(d.Changed) > 0 {
+		buff.WriteString("### Changed extensions\n\n")
+
+		for _, ext := range d.Changed {
+			ext.writeMarkdown(&buff)
+		}
+	}
+
+	_, err := io.WriteString(out, buff.String())
+
+	return err
+}
+
+func (d *extensionDiff) writeMarkdown(buff *strings.Builder) {
+	fmt.Fprintf(buff, "#### `%s`\n\n", d.Module)
+
+	if len(d.AddedVersions) > 0 {
+		fmt.Fprintf(buff, "- New versions: %s\n", strings.Join(d.AddedVersions, ", "))
+	}
+
+	if len(d.RemovedVersions) > 0 {
+		fmt.Fprintf(buff, "- Removed versions: %s\n", strings.Join(d.RemovedVersions, ", "))
+	}
+
+	if d.Tier != nil {
+		fmt.Fprintf(buff, "- Tier: %s → %s\n", d.Tier.From, d.Tier.To)
+	}
+
+	if d.Archived {
+		buff.WriteString("- :warning: Repository archived\n")
+	}
+
+	for _, reg := range d.ComplianceRegressions {
+		fmt.Fprintf(buff, "- :x: Compliance regression in %s: %s\n", reg.Version, strings.Join(reg.Issues, ", "))
+	}
+
+	buff.WriteString("\n")
+}
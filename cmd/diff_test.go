@@ -0,0 +1,141 @@
+package cmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func testDiffRegistries() (k6registry.Registry, k6registry.Registry) {
+	from := k6registry.Registry{
+		{
+			Module:   "github.com/grafana/xk6-foo",
+			Tier:     k6registry.TierCommunity,
+			Versions: []string{"v0.2.0", "v0.1.0"},
+			Repo:     &k6registry.Repository{Name: "xk6-foo"},
+			Compliance: k6registry.ExtensionCompliance{
+				"v0.2.0": {Issues: []string{"readme"}},
+				"v0.1.0": {Issues: []string{"readme"}},
+			},
+		},
+		{Module: "github.com/grafana/xk6-old", Versions: []string{"v1.0.0"}},
+		{Module: "github.com/grafana/xk6-same", Versions: []string{"v1.0.0"}, Repo: &k6registry.Repository{Name: "xk6-same"}},
+	}
+
+	to := k6registry.Registry{
+		{
+			Module:   "github.com/grafana/xk6-foo",
+			Tier:     k6registry.TierOfficial,
+			Versions: []string{"v0.3.0", "v0.2.0"},
+			Repo:     &k6registry.Repository{Name: "xk6-foo", Archived: true},
+			Compliance: k6registry.ExtensionCompliance{
+				"v0.3.0": {Issues: []string{"readme", "license"}},
+				"v0.2.0": {Issues: []string{"readme"}},
+			},
+		},
+		{Module: "github.com/grafana/xk6-same", Versions: []string{"v1.0.0"}, Repo: &k6registry.Repository{Name: "xk6-same"}},
+		{Module: "github.com/grafana/xk6-new", Tier: k6registry.TierCommunity, Versions: []string{"v0.1.0"}},
+	}
+
+	return from, to
+}
+
+func TestDiffRegistries(t *testing.T) {
+	t.Parallel()
+
+	from, to := testDiffRegistries()
+
+	diff := diffRegistries(from, to)
+
+	want := &registryDiff{
+		Added: []addedExtension{
+			{Module: "github.com/grafana/xk6-new", Tier: k6registry.TierCommunity, Versions: []string{"v0.1.0"}},
+		},
+		Removed: []string{"github.com/grafana/xk6-old"},
+		Changed: []extensionDiff{
+			{
+				Module:          "github.com/grafana/xk6-foo",
+				AddedVersions:   []string{"v0.3.0"},
+				RemovedVersions: []string{"v0.1.0"},
+				Tier:            &tierChange{From: k6registry.TierCommunity, To: k6registry.TierOfficial},
+				Archived:        true,
+				ComplianceRegressions: []complianceRegression{
+					{Version: "v0.3.0", Issues: []string{"license"}},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("got diff %+v, want %+v", diff, want)
+	}
+
+	if !diffRegistries(to, to).empty() {
+		t.Fatal("expected no changes between identical registries")
+	}
+}
+
+func TestDiffCmd(t *testing.T) {
+	t.Parallel()
+
+	from, to := testDiffRegistries()
+
+	dir := t.TempDir()
+
+	for name, registry := range map[string]k6registry.Registry{"old.json": from, "new.json": to} {
+		data, err := json.Marshal(registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		writeFileT(t, dir, name, string(data))
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+
+		cmd := diffCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(append(args, filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")))
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		return out.String()
+	}
+
+	markdown := run()
+
+	for _, want := range []string{
+		"### Added extensions\n\n- `github.com/grafana/xk6-new` (community): v0.1.0\n",
+		"### Removed extensions\n\n- `github.com/grafana/xk6-old`\n",
+		"#### `github.com/grafana/xk6-foo`\n\n- New versions: v0.3.0\n- Removed versions: v0.1.0\n- Tier: community → official\n",
+		"Compliance regression in v0.3.0: license\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected %q in markdown report:\n%s", want, markdown)
+		}
+	}
+
+	if strings.Contains(markdown, "xk6-same") {
+		t.Errorf("unchanged extension in markdown report:\n%s", markdown)
+	}
+
+	var diff registryDiff
+
+	if err := json.Unmarshal([]byte(run("--format", "json")), &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.Added) != 1 || len(diff.Removed) != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("unexpected JSON report %+v", diff)
+	}
+}
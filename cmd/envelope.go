@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+// envelopeFormats contains the output formats supporting the envelope.
+var envelopeFormats = []string{"json", "yaml"} //nolint:gochecknoglobals
+
+// newEnvelope returns the registry wrapped with the metadata of the generation.
+func newEnvelope(registry k6registry.Registry, sourceSHA256 string, version string, now time.Time) (*k6registry.Envelope, error) {
+	digests := make(k6registry.EnvelopeDigests, len(registry))
+
+	for idx := range registry {
+		digest, err := extensionDigest(&registry[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		digests[registry[idx].Module] = digest
+	}
+
+	return &k6registry.Envelope{
+		Registry:         registry,
+		GeneratedAt:      now.UTC().Format(time.RFC3339),
+		GeneratorVersion: version,
+		SourceSha256:     sourceSHA256,
+		Digests:          digests,
+	}, nil
+}
+
+// extensionDigest returns the SHA-256 digest of the compact JSON encoding of the extension.
+// The extension is encoded the same way as in the compact JSON output (without HTML escaping).
+func extensionDigest(ext *k6registry.Extension) (string, error) {
+	var buff bytes.Buffer
+
+	if err := newJSONEncoder(&buff, true).Encode(ext); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bytes.TrimSuffix(buff.Bytes(), []byte("\n")))
+
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// encodeEnvelope writes the envelope in the format to output.
+func encodeEnvelope(envelope *k6registry.Envelope, format string, output io.Writer, compact bool) error {
+	switch format {
+	case "json":
+		return newJSONEncoder(output, compact).Encode(envelope)
+	case "yaml":
+		return writeYAML(output, envelope)
+	default:
+		return fmt.Errorf("%w: the envelope is not supported in %s format", errInvalidFormat, format)
+	}
+}
@@ -0,0 +1,176 @@
+package cmd //nolint:testpackage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/k6registry"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	t.Parallel()
+
+	registry := testFilterRegistry()
+	registry[0].Description = "Load test SQL databases & <others>"
+
+	now := time.Date(2024, 9, 10, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	envelope, err := newEnvelope(registry, "abcd", "v1.2.3", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if envelope.GeneratedAt != "2024-09-10T10:00:00Z" || envelope.GeneratorVersion != "v1.2.3" ||
+		envelope.SourceSha256 != "abcd" || len(envelope.Registry) != len(registry) {
+		t.Fatalf("unexpected envelope %+v", envelope)
+	}
+
+	// the digest matches the extension as written in the compact JSON output
+	var buff bytes.Buffer
+
+	if err := encodeJSON(registry, &buff, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var written []json.RawMessage
+
+	if err := json.Unmarshal(buff.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(written[0], []byte("& <others>")) {
+		t.Fatalf("unexpected output %s", written[0])
+	}
+
+	sum := sha256.Sum256(written[0])
+
+	if want := "sha256:" + hex.EncodeToString(sum[:]); envelope.Digests[registry[0].Module] != want {
+		t.Fatalf("got digest %s, want %s", envelope.Digests[registry[0].Module], want)
+	}
+
+	buff.Reset()
+
+	if err := encodeEnvelope(envelope, "json", &buff, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// the envelope definition of the registry schema is used as root schema
+	var schema map[string]any
+
+	if err := json.Unmarshal(k6registry.Schema, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	schema["$ref"] = "#/$defs/envelope"
+
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewBytesLoader(buff.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Valid() {
+		t.Fatalf("envelope is not valid: %v", result.Errors())
+	}
+
+	if err := encodeEnvelope(envelope, "csv", &buff, false); !errors.Is(err, errInvalidFormat) {
+		t.Fatalf("got error %v, want %v", err, errInvalidFormat)
+	}
+}
+
+func TestRun_Envelope(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	dir := t.TempDir()
+	source := "- module: example.com/xk6-foo\n"
+
+	writeFileT(t, dir, "registry.yaml", source)
+
+	out := filepath.Join(dir, "registry.json")
+	opts := &options{out: out, format: defaultFormat, envelope: true, version: "v1.2.3"}
+
+	if err := run(ctx, []string{filepath.Join(dir, "registry.yaml")}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var envelope k6registry.Envelope
+
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte(source))
+
+	if envelope.SourceSha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("got source digest %s", envelope.SourceSha256)
+	}
+
+	if envelope.GeneratorVersion != "v1.2.3" || len(envelope.Registry) != 2 || len(envelope.Digests) != 2 {
+		t.Errorf("unexpected envelope %+v", envelope)
+	}
+
+	// the envelope is accepted as previous registry
+	previous := filepath.Join(dir, "previous.json")
+
+	writeFileT(t, dir, "previous.json", string(data))
+
+	opts.previous = previous
+
+	if err := run(ctx, []string{filepath.Join(dir, "registry.yaml")}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opts.loadOptions.previous) != 2 || opts.loadOptions.previous["example.com/xk6-foo"] == nil {
+		t.Errorf("unexpected previous extensions %v", opts.loadOptions.previous)
+	}
+
+	// and by the diff subcommand
+	var report bytes.Buffer
+
+	cmd := diffCmd()
+	cmd.SetOut(&report)
+	cmd.SetArgs([]string{"--format", "json", previous, out})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	var diff registryDiff
+
+	if err := json.Unmarshal(report.Bytes(), &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 || len(diff.Changed) != 0 {
+		t.Errorf("unexpected diff of the same registry %+v", diff)
+	}
+
+	writeFileT(t, dir, "invalid.json", `{"generator_version":"v1.2.3"}`)
+
+	if _, err := readRegistry(filepath.Join(dir, "invalid.json")); !errors.Is(err, errInvalidRegistry) {
+		t.Errorf("got error %v, want %v", err, errInvalidRegistry)
+	}
+
+	opts.previous = ""
+
+	opts.format = "csv"
+
+	if err := run(ctx, []string{filepath.Join(dir, "registry.yaml")}, opts); !errors.Is(err, errInvalidFormat) {
+		t.Fatalf("got error %v, want %v", err, errInvalidFormat)
+	}
+}
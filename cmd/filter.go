@@ -0,0 +1,570 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/grafana/k6registry"
+)
+
+var (
+	errInvalidFilter = errors.New("invalid filter")
+	errUnknownField  = errors.New("unknown field")
+)
+
+// filterExpr evaluates a filter expression on an extension converted to a JSON object.
+type filterExpr func(ext map[string]any) any
+
+// compileFilter compiles a filter expression.
+//
+// The expression is evaluated for each extension. The fields are referenced using their
+// JSON schema property names and dotted paths (e.g. tier, repo.archived, repo.stars).
+// Supported operators, in decreasing precedence:
+//
+//	!                                 logical not
+//	== != < <= > >= in                comparison, membership ("k6/x/sql" in imports)
+//	&&                                logical and
+//	||                                logical or
+//
+// String ("official" or 'official'), number, true, false and null literals and
+// parentheses can be used. Missing fields are null. The false, null, zero, empty
+// string, empty array and empty object values are false, everything else is true.
+func compileFilter(source string) (filterExpr, error) {
+	tokens, err := tokenizeFilter(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	parser := &filterParser{tokens: tokens}
+
+	expr, err := parser.parseOr()
+	if err == nil && parser.pos < len(parser.tokens) {
+		err = fmt.Errorf("%w: unexpected %q", errInvalidFilter, parser.tokens[parser.pos].text)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	return expr, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokenPath filterTokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+var filterOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} //nolint:gochecknoglobals
+
+func tokenizeFilter(source string) ([]filterToken, error) {
+	var tokens []filterToken
+
+	for pos := 0; pos < len(source); {
+		char := rune(source[pos])
+
+		switch {
+		case unicode.IsSpace(char):
+			pos++
+
+		case char == '"' || char == '\'':
+			end := pos + 1
+
+			for end < len(source) && source[end] != source[pos] {
+				if source[end] == '\\' && char == '"' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(source) {
+				return nil, fmt.Errorf("%w: unterminated string", errInvalidFilter)
+			}
+
+			text := source[pos+1 : end]
+
+			if char == '"' {
+				unquoted, err := strconv.Unquote(source[pos : end+1])
+				if err != nil {
+					return nil, fmt.Errorf("%w: %w", errInvalidFilter, err)
+				}
+
+				text = unquoted
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenString, text: text})
+			pos = end + 1
+
+		case char == '-' || unicode.IsDigit(char):
+			end := pos + 1
+
+			for end < len(source) && (unicode.IsDigit(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenNumber, text: source[pos:end]})
+			pos = end
+
+		case char == '_' || unicode.IsLetter(char):
+			end := pos + 1
+
+			for end < len(source) && isPathChar(rune(source[end])) {
+				end++
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenPath, text: source[pos:end]})
+			pos = end
+
+		default:
+			idx := slices.IndexFunc(filterOperators, func(op string) bool {
+				return strings.HasPrefix(source[pos:], op)
+			})
+
+			if idx < 0 {
+				return nil, fmt.Errorf("%w: unexpected character %q", errInvalidFilter, char)
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenOperator, text: filterOperators[idx]})
+			pos += len(filterOperators[idx])
+		}
+	}
+
+	return tokens, nil
+}
+
+func isPathChar(char rune) bool {
+	return char == '_' || char == '.' || unicode.IsLetter(char) || unicode.IsDigit(char)
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// accept consumes the next token if it is the given operator or keyword.
+func (p *filterParser) accept(text string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind != tokenString && p.tokens[p.pos].text == text {
+		p.pos++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = func(lhs, rhs filterExpr) filterExpr {
+			return func(ext map[string]any) any { return truthy(lhs(ext)) || truthy(rhs(ext)) }
+		}(left, right)
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+
+		left = func(lhs, rhs filterExpr) filterExpr {
+			return func(ext map[string]any) any { return truthy(lhs(ext)) && truthy(rhs(ext)) }
+		}(left, right)
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if !p.accept(op) {
+			continue
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ext map[string]any) any { return compareValues(op, left(ext), right(ext)) }, nil
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ext map[string]any) any { return !truthy(operand(ext)) }, nil
+	}
+
+	return p.parseOperand()
+}
+
+func (p *filterParser) parseOperand() (filterExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected end of expression", errInvalidFilter)
+	}
+
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept(")") {
+			return nil, fmt.Errorf("%w: missing )", errInvalidFilter)
+		}
+
+		return expr, nil
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case tokenString:
+		return constant(token.text), nil
+	case tokenNumber:
+		num, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %q", errInvalidFilter, token.text)
+		}
+
+		return constant(num), nil
+	case tokenPath:
+		return parsePath(token.text)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q", errInvalidFilter, token.text)
+	}
+}
+
+func parsePath(text string) (filterExpr, error) {
+	switch text {
+	case "true":
+		return constant(true), nil
+	case "false":
+		return constant(false), nil
+	case "null":
+		return constant(nil), nil
+	}
+
+	path := strings.Split(text, ".")
+
+	if err := validateFieldPath(path); err != nil {
+		return nil, err
+	}
+
+	return func(ext map[string]any) any {
+		var value any = ext
+
+		for _, name := range path {
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return nil
+			}
+
+			value = obj[name]
+		}
+
+		return value
+	}, nil
+}
+
+func constant(value any) filterExpr {
+	return func(map[string]any) any { return value }
+}
+
+// truthy reports whether value is considered true.
+func truthy(value any) bool {
+	switch val := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return len(val) > 0
+	case []any:
+		return len(val) > 0
+	case map[string]any:
+		return len(val) > 0
+	default:
+		return true
+	}
+}
+
+// compareValues applies the comparison operator to the values.
+// Ordering is defined for numbers and for strings only.
+func compareValues(op string, left any, right any) bool {
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	case "in":
+		return contains(right, left)
+	}
+
+	var cmp int
+
+	switch lhs := left.(type) {
+	case float64:
+		rhs, ok := right.(float64)
+		if !ok {
+			return false
+		}
+
+		cmp = compareOrdered(lhs, rhs)
+	case string:
+		rhs, ok := right.(string)
+		if !ok {
+			return false
+		}
+
+		cmp = strings.Compare(lhs, rhs)
+	default:
+		return false
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func compareOrdered(lhs float64, rhs float64) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// contains reports whether the array contains the element, the object contains the key
+// or the string contains the substring.
+func contains(container any, element any) bool {
+	switch val := container.(type) {
+	case []any:
+		return slices.ContainsFunc(val, func(item any) bool { return reflect.DeepEqual(item, element) })
+	case map[string]any:
+		key, ok := element.(string)
+		_, found := val[key]
+
+		return ok && found
+	case string:
+		sub, ok := element.(string)
+
+		return ok && strings.Contains(val, sub)
+	default:
+		return false
+	}
+}
+
+// schemaDefs returns the definitions of the registry JSON schema.
+var schemaDefs = sync.OnceValue(func() map[string]any { //nolint:gochecknoglobals
+	var schema struct {
+		Defs map[string]any `json:"$defs"`
+	}
+
+	_ = json.Unmarshal(k6registry.Schema, &schema)
+
+	return schema.Defs
+})
+
+// resolveSchema returns the schema definition referenced by node, or node itself.
+func resolveSchema(node map[string]any) map[string]any {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+
+	def, _ := schemaDefs()[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+
+	return def
+}
+
+// validateFieldPath checks the field path against the property names of the extension in the JSON schema.
+func validateFieldPath(path []string) error {
+	node, _ := schemaDefs()["extension"].(map[string]any)
+
+	for idx, name := range path {
+		node = resolveSchema(node)
+
+		if props, ok := node["properties"].(map[string]any); ok {
+			if prop, found := props[name].(map[string]any); found {
+				node = prop
+
+				continue
+			}
+		}
+
+		// objects with arbitrary keys, e.g. compliance results by version
+		if additional, ok := node["additionalProperties"].(map[string]any); ok {
+			node = additional
+
+			continue
+		}
+
+		return fmt.Errorf("%w: %s", errUnknownField, strings.Join(path[:idx+1], "."))
+	}
+
+	return nil
+}
+
+// toObject converts the extension to a JSON object.
+func toObject(ext *k6registry.Extension) (map[string]any, error) {
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]any
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// outputQuery selects the extensions and the extension properties written to the output.
+type outputQuery struct {
+	filter filterExpr
+	fields []string
+}
+
+// newOutputQuery returns a query using the filter expression (all extensions if empty)
+// and the selected top level properties (all properties if empty).
+// The module property is always selected.
+func newOutputQuery(filter string, fields []string) (*outputQuery, error) {
+	query := new(outputQuery)
+
+	if len(strings.TrimSpace(filter)) > 0 {
+		expr, err := compileFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		query.filter = expr
+	}
+
+	for _, field := range fields {
+		if err := validateFieldPath([]string{field}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fields) > 0 {
+		query.fields = append([]string{"module"}, fields...)
+	}
+
+	return query, nil
+}
+
+// apply returns the selected extensions of the registry with the selected properties.
+func (q *outputQuery) apply(registry k6registry.Registry) (k6registry.Registry, error) {
+	if q.filter == nil && len(q.fields) == 0 {
+		return registry, nil
+	}
+
+	result := make(k6registry.Registry, 0, len(registry))
+
+	for idx := range registry {
+		obj, err := toObject(&registry[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if q.filter != nil && !truthy(q.filter(obj)) {
+			continue
+		}
+
+		ext := registry[idx]
+
+		if len(q.fields) > 0 {
+			ext, err = selectFields(obj, q.fields)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, ext)
+	}
+
+	return result, nil
+}
+
+// selectFields returns the extension containing only the given top level properties of obj.
+func selectFields(obj map[string]any, fields []string) (k6registry.Extension, error) {
+	selected := make(map[string]any, len(fields))
+
+	for _, field := range fields {
+		if value, found := obj[field]; found {
+			selected[field] = value
+		}
+	}
+
+	var ext k6registry.Extension
+
+	data, err := json.Marshal(selected)
+	if err != nil {
+		return ext, err
+	}
+
+	err = json.Unmarshal(data, &ext)
+
+	return ext, err
+}
@@ -0,0 +1,146 @@
+package cmd //nolint:testpackage
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func testFilterRegistry() k6registry.Registry {
+	return k6registry.Registry{
+		{
+			Module:   "github.com/grafana/xk6-sql",
+			Tier:     k6registry.TierOfficial,
+			Imports:  []string{"k6/x/sql"},
+			Versions: []string{"v1.0.0"},
+			Repo:     &k6registry.Repository{Name: "xk6-sql", Stars: 120, License: "AGPL-3.0"},
+		},
+		{
+			Module:  "github.com/grafana/xk6-dashboard",
+			Tier:    k6registry.TierOfficial,
+			Outputs: []string{"dashboard"},
+			Repo:    &k6registry.Repository{Name: "xk6-dashboard", Stars: 300, Archived: true},
+		},
+		{
+			Module:     "github.com/example/xk6-foo",
+			Tier:       k6registry.TierCommunity,
+			Imports:    []string{"k6/x/foo"},
+			Repo:       &k6registry.Repository{Name: "xk6-foo", Stars: 3},
+			Compliance: k6registry.ExtensionCompliance{"v0.1.0": {Issues: []string{"readme"}}},
+		},
+	}
+}
+
+func TestCompileFilter(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		filter string
+		want   []string
+	}{
+		{`tier == "official"`, []string{"xk6-sql", "xk6-dashboard"}},
+		{`tier == "official" && !repo.archived`, []string{"xk6-sql"}},
+		{`tier != 'official' || repo.stars >= 300`, []string{"xk6-dashboard", "xk6-foo"}},
+		{`repo.stars > 3 && repo.stars < 300`, []string{"xk6-sql"}},
+		{`"k6/x/sql" in imports`, []string{"xk6-sql"}},
+		{`"xk6" in module && !(tier == "community")`, []string{"xk6-sql", "xk6-dashboard"}},
+		{`"v0.1.0" in compliance`, []string{"xk6-foo"}},
+		{`outputs`, []string{"xk6-dashboard"}},
+		{`repo.license == null`, []string{"xk6-dashboard", "xk6-foo"}},
+		{`true`, []string{"xk6-sql", "xk6-dashboard", "xk6-foo"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.filter, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := newOutputQuery(c.filter, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			registry, err := query.apply(testFilterRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := make([]string, 0, len(registry))
+			for _, ext := range registry {
+				names = append(names, ext.Repo.Name)
+			}
+
+			if !slices.Equal(names, c.want) {
+				t.Fatalf("got %v, want %v", names, c.want)
+			}
+		})
+	}
+}
+
+func TestCompileFilter_Invalid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]error{
+		`tier ==`:                  errInvalidFilter,
+		`tier == "official`:        errInvalidFilter,
+		`(tier == "official"`:      errInvalidFilter,
+		`tier = "official"`:        errInvalidFilter,
+		`tier "official"`:          errInvalidFilter,
+		`teir == "official"`:       errUnknownField,
+		`repo.stargazers > 10`:     errUnknownField,
+		`tier.name == "official"`:  errUnknownField,
+		`compliance.v1.issues`:     nil,
+		`repo.stars >= 10 && cgo`:  nil,
+		`version_source == "tags"`: nil,
+	}
+
+	for filter, want := range cases {
+		_, err := compileFilter(filter)
+
+		if want == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", filter, err)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, want) {
+			t.Errorf("%s: got error %v, want %v", filter, err, want)
+		}
+	}
+}
+
+func TestOutputQuery_Select(t *testing.T) {
+	t.Parallel()
+
+	query, err := newOutputQuery(`tier == "official"`, []string{"versions", "tier"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := query.apply(testFilterRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(registry) != 2 {
+		t.Fatalf("got %d extensions, want 2", len(registry))
+	}
+
+	sql := registry[0]
+
+	if sql.Module != "github.com/grafana/xk6-sql" || sql.Tier != k6registry.TierOfficial ||
+		!slices.Equal(sql.Versions, []string{"v1.0.0"}) {
+		t.Fatalf("missing selected properties %+v", sql)
+	}
+
+	if sql.Repo != nil || sql.Imports != nil {
+		t.Fatalf("unexpected not selected properties %+v", sql)
+	}
+
+	if _, err := newOutputQuery("", []string{"repo.stars"}); !errors.Is(err, errUnknownField) {
+		t.Fatalf("got error %v, want %v", err, errUnknownField)
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/k6registry"
+	"gopkg.in/yaml.v3"
+)
+
+var errInvalidFormat = errors.New("invalid format")
+
+const defaultFormat = "json"
+
+// registryEncoder writes the registry to output.
+// The compact flag requests the most compact form supported by the format.
+type registryEncoder func(registry k6registry.Registry, output io.Writer, compact bool) error
+
+// registryEncoders contains the supported output formats by name.
+// New formats can be supported by adding their encoder to the set.
+var registryEncoders = map[string]registryEncoder{ //nolint:gochecknoglobals
+	"json":    encodeJSON,
+	"yaml":    encodeYAML,
+	"ndjson":  encodeNDJSON,
+	"csv":     encodeCSV,
+	"catalog": encodeCatalog,
+}
+
+// formatNames returns the names of the supported output formats.
+func formatNames() []string {
+	names := make([]string, 0, len(registryEncoders))
+
+	for name := range registryEncoders {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// findEncoder returns the encoder of the format.
+func findEncoder(format string) (registryEncoder, error) {
+	encoder, found := registryEncoders[format]
+	if !found {
+		return nil, fmt.Errorf("%w: %s (supported formats: %s)", errInvalidFormat, format, strings.Join(formatNames(), ", "))
+	}
+
+	return encoder, nil
+}
+
+func newJSONEncoder(output io.Writer, compact bool) *json.Encoder {
+	encoder := json.NewEncoder(output)
+
+	if !compact {
+		encoder.SetIndent("", "  ")
+	}
+
+	encoder.SetEscapeHTML(false)
+
+	return encoder
+}
+
+// encodeJSON writes the registry as a JSON array.
+func encodeJSON(registry k6registry.Registry, output io.Writer, compact bool) error {
+	var source any = registry
+
+	return newJSONEncoder(output, compact).Encode(source)
+}
+
+// encodeNDJSON writes the extensions as newline delimited JSON, one extension per line.
+func encodeNDJSON(registry k6registry.Registry, output io.Writer, _ bool) error {
+	encoder := newJSONEncoder(output, true)
+
+	for idx := range registry {
+		if err := encoder.Encode(&registry[idx]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeYAML writes the registry as a YAML sequence.
+func encodeYAML(registry k6registry.Registry, output io.Writer, _ bool) error {
+	return writeYAML(output, registry)
+}
+
+// writeYAML writes value as a YAML document.
+// The repository timestamps are written as integers instead of the exponent form of large floats.
+func writeYAML(output io.Writer, value any) error {
+	const indent = 2
+
+	var node yaml.Node
+
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+
+	integerTimestamps(&node)
+
+	encoder := yaml.NewEncoder(output)
+
+	encoder.SetIndent(indent)
+
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// integerTimestamps rewrites the whole number timestamp values of the YAML node tree as integers.
+func integerTimestamps(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			key, value := node.Content[idx], node.Content[idx+1]
+
+			if key.Value != "timestamp" || value.Tag != "!!float" {
+				continue
+			}
+
+			if ts, err := strconv.ParseFloat(value.Value, 64); err == nil && ts == math.Trunc(ts) {
+				value.Tag, value.Value = "!!int", strconv.FormatFloat(ts, 'f', -1, 64)
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		integerTimestamps(child)
+	}
+}
+
+// csvHeader contains the column names of the CSV output.
+var csvHeader = []string{"module", "tier", "license", "stars", "latest_version"} //nolint:gochecknoglobals
+
+// encodeCSV writes the main properties of the extensions as CSV, one extension per row.
+func encodeCSV(registry k6registry.Registry, output io.Writer, _ bool) error {
+	writer := csv.NewWriter(output)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, ext := range registry {
+		var license, stars, latest string
+
+		if ext.Repo != nil {
+			license = ext.Repo.License
+			stars = strconv.Itoa(ext.Repo.Stars)
+		}
+
+		if len(ext.Versions) > 0 {
+			latest = ext.Versions[0]
+		}
+
+		if err := writer.Write([]string{ext.Module, string(ext.Tier), license, stars, latest}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,134 @@
+package cmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+	"gopkg.in/yaml.v3"
+)
+
+func testFormatRegistry() k6registry.Registry {
+	return k6registry.Registry{
+		{
+			Module:   "github.com/grafana/xk6-foo",
+			Tier:     k6registry.TierOfficial,
+			Imports:  []string{"k6/x/foo"},
+			Versions: []string{"v0.2.0", "v0.1.0"},
+			Repo: &k6registry.Repository{
+				Name: "xk6-foo", Owner: "grafana", License: "AGPL-3.0", Stars: 42, Timestamp: 1726000000,
+			},
+		},
+		{
+			Module:  "github.com/grafana/xk6-bar",
+			Tier:    k6registry.TierCommunity,
+			Outputs: []string{"bar"},
+		},
+	}
+}
+
+func encodeT(t *testing.T, format string, compact bool) string {
+	t.Helper()
+
+	encoder, err := findEncoder(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	if err := encoder(testFormatRegistry(), &out, compact); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.String()
+}
+
+func TestFindEncoder(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"json", "yaml", "ndjson", "csv"} {
+		if _, err := findEncoder(format); err != nil {
+			t.Errorf("format %s: %v", format, err)
+		}
+	}
+
+	if _, err := findEncoder("xml"); !errors.Is(err, errInvalidFormat) {
+		t.Fatalf("got error %v, want %v", err, errInvalidFormat)
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	t.Parallel()
+
+	var registry k6registry.Registry
+
+	if err := json.Unmarshal([]byte(encodeT(t, "json", false)), &registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(registry) != 2 || registry[0].Repo.Stars != 42 {
+		t.Fatalf("unexpected registry %+v", registry)
+	}
+
+	if compact := encodeT(t, "json", true); strings.Count(compact, "\n") != 1 {
+		t.Fatalf("expected a single line compact output, got %q", compact)
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	t.Parallel()
+
+	var registry k6registry.Registry
+
+	out := encodeT(t, "yaml", false)
+
+	if err := yaml.Unmarshal([]byte(out), &registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(registry) != 2 || registry[0].Repo.License != "AGPL-3.0" || registry[1].Outputs[0] != "bar" {
+		t.Fatalf("unexpected registry %+v", registry)
+	}
+
+	if !strings.Contains(out, "timestamp: 1726000000\n") || registry[0].Repo.Timestamp != 1726000000 {
+		t.Fatalf("timestamp not written as integer:\n%s", out)
+	}
+}
+
+func TestEncodeNDJSON(t *testing.T) {
+	t.Parallel()
+
+	lines := strings.Split(strings.TrimSpace(encodeT(t, "ndjson", false)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	for idx, line := range lines {
+		var ext k6registry.Extension
+
+		if err := json.Unmarshal([]byte(line), &ext); err != nil {
+			t.Fatal(err)
+		}
+
+		if ext.Module != testFormatRegistry()[idx].Module {
+			t.Fatalf("line %d: got module %q", idx, ext.Module)
+		}
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	t.Parallel()
+
+	want := `module,tier,license,stars,latest_version
+github.com/grafana/xk6-foo,official,AGPL-3.0,42,v0.2.0
+github.com/grafana/xk6-bar,community,,,
+`
+
+	if got := encodeT(t, "csv", false); got != want {
+		t.Fatalf("got CSV\n%s\nwant\n%s", got, want)
+	}
+}
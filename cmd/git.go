@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
 const gitBinary = "git"
@@ -22,6 +23,21 @@ func checkGitAvailable() error {
 	return nil
 }
 
+// repoLocks holds a *sync.Mutex per repository directory.
+var repoLocks sync.Map //nolint:gochecknoglobals
+
+// lockRepo serializes operations that modify the repository at dir (clone, fetch,
+// worktree add/remove), so concurrent compliance checks can share the same mirror.
+// It returns the unlock function.
+func lockRepo(dir string) func() {
+	value, _ := repoLocks.LoadOrStore(dir, new(sync.Mutex))
+
+	mu, _ := value.(*sync.Mutex)
+	mu.Lock()
+
+	return mu.Unlock
+}
+
 // runGit runs git with args, using dir as the working directory (ignored if empty).
 func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, gitBinary, args...) //nolint:gosec // git is a fixed, trusted binary
@@ -46,6 +62,8 @@ func openOrCloneBareRepo(ctx context.Context, dir string, cloneURL string) error
 		return err
 	}
 
+	defer lockRepo(dir)()
+
 	_, err := os.Stat(dir) //nolint:gosec,forbidigo // modules cache dir
 	if err == nil {
 		return nil
@@ -72,6 +90,34 @@ func listTags(ctx context.Context, dir string) ([]string, error) {
 	return strings.Fields(string(out)), nil
 }
 
+// listRemoteTags returns the tag names of the remote repository at url, without cloning it.
+func listRemoteTags(ctx context.Context, url string) ([]string, error) {
+	if err := checkGitAvailable(); err != nil {
+		return nil, err
+	}
+
+	out, err := runGit(ctx, "", "ls-remote", "--tags", "--refs", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+
+	// Each line contains the object name and the ref name separated by a tab.
+	for _, line := range strings.Split(string(out), "\n") {
+		_, ref, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+
+		if tag, ok := strings.CutPrefix(strings.TrimSpace(ref), "refs/tags/"); ok {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
 // defaultBranch returns the branch name that dir's HEAD points to.
 func defaultBranch(ctx context.Context, dir string) (string, error) {
 	out, err := runGit(ctx, dir, "symbolic-ref", "--short", "HEAD")
@@ -90,6 +136,8 @@ func checkoutWorktree(ctx context.Context, repoDir string, version string) (stri
 		return "", nil, err
 	}
 
+	defer lockRepo(repoDir)()
+
 	if _, err := runGit(ctx, repoDir, "fetch", "--prune", "origin"); err != nil {
 		return "", nil, err
 	}
@@ -117,6 +165,8 @@ func checkoutWorktree(ctx context.Context, repoDir string, version string) (stri
 	}
 
 	cleanup := func() error {
+		defer lockRepo(repoDir)()
+
 		if _, err := runGit(ctx, repoDir, "worktree", "remove", "--force", worktreeDir); err != nil {
 			_ = os.RemoveAll(worktreeDir) //nolint:forbidigo // best-effort cleanup fallback
 			_, _ = runGit(ctx, repoDir, "worktree", "prune")
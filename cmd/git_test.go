@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
@@ -160,6 +161,22 @@ func TestListTags(t *testing.T) {
 	}
 }
 
+func TestListRemoteTags(t *testing.T) {
+	requireGit(t)
+	t.Parallel()
+
+	tags, err := listRemoteTags(context.Background(), newTestRemote(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(tags)
+
+	if want := []string{"not-a-version", "v1.0.0", "v1.1.0"}; !slices.Equal(tags, want) {
+		t.Fatalf("got tags %v, want %v", tags, want)
+	}
+}
+
 func TestLoadGit(t *testing.T) {
 	requireGit(t)
 	t.Parallel()
@@ -328,6 +345,40 @@ func TestCheckoutWorktree_Concurrent(t *testing.T) {
 	}
 }
 
+func TestOpenOrCloneBareRepo_Concurrent(t *testing.T) {
+	requireGit(t)
+	t.Parallel()
+
+	ctx := context.Background()
+	remote := newTestRemote(t)
+	dest := filepath.Join(t.TempDir(), "repo")
+
+	const workers = 4
+
+	results := make(chan error, workers)
+
+	for range workers {
+		go func() {
+			results <- openOrCloneBareRepo(ctx, dest, remote)
+		}()
+	}
+
+	for range workers {
+		if err := <-results; err != nil {
+			t.Fatalf("concurrent clone failed: %v", err)
+		}
+	}
+
+	tags, err := listTags(ctx, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 3 {
+		t.Fatalf("got tags %v, want 3 entries", tags)
+	}
+}
+
 func TestCheckGitAvailable_MissingBinary(t *testing.T) {
 	t.Setenv("PATH", "")
 
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+const (
+	cbModulePrefix = "codeberg.org/"
+	cbBaseURL      = "https://codeberg.org"
+
+	giteaPageSize = 50
+)
+
+// giteaProvider loads repository metadata using the Gitea API.
+// Forgejo (and therefore Codeberg) implements the same API.
+type giteaProvider struct {
+	prefix  string
+	baseURL string
+	client  *http.Client
+}
+
+// newGiteaProvider returns a provider for modules starting with prefix,
+// served by the Gitea or Forgejo instance at baseURL.
+// The access token is optional, it is required only for private repositories.
+func newGiteaProvider(prefix string, baseURL string, token string) *giteaProvider {
+	return &giteaProvider{
+		prefix:  prefix,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  newProviderHTTPClient("token", token),
+	}
+}
+
+func (p *giteaProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+// giteaRepository contains the used properties of the Gitea API repository object.
+type giteaRepository struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Description string    `json:"description"`
+	Website     string    `json:"website"`
+	HTMLURL     string    `json:"html_url"`
+	CloneURL    string    `json:"clone_url"`
+	Stars       int       `json:"stars_count"`
+	Archived    bool      `json:"archived"`
+	Private     bool      `json:"private"`
+	Topics      []string  `json:"topics"`
+	Licenses    []string  `json:"licenses"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// giteaRef contains the used properties of the Gitea API tag and release objects.
+type giteaRef struct {
+	Name       string `json:"name"`
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// tagName returns the name of the tag, or the tag name of the release.
+func (r *giteaRef) tagName() string {
+	if len(r.TagName) > 0 {
+		return r.TagName
+	}
+
+	return r.Name
+}
+
+func (p *giteaProvider) load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	module := query.module
+
+	slog.Debug("Loading Gitea repository", "module", module) //nolint:gosec // debug log
+
+	owner, name := moduleToOwnerAndName(p.prefix, module)
+
+	base := p.baseURL + "/api/v1/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+
+	var rep giteaRepository
+
+	if err := getJSON(ctx, p.client, base, &rep); err != nil {
+		return nil, nil, err
+	}
+
+	repo := new(k6registry.Repository)
+
+	repo.Name = rep.Name
+	repo.Owner = rep.Owner.Login
+	repo.Description = rep.Description
+	repo.URL = rep.HTMLURL
+
+	repo.Homepage = rep.Website
+	if len(repo.Homepage) == 0 {
+		repo.Homepage = repo.URL
+	}
+
+	repo.Stars = rep.Stars
+	repo.Archived = rep.Archived
+	repo.Public = !rep.Private
+	repo.Topics = rep.Topics
+	repo.CloneURL = rep.CloneURL
+
+	if !rep.UpdatedAt.IsZero() {
+		repo.Timestamp = float64(rep.UpdatedAt.Unix())
+	}
+
+	for _, lic := range rep.Licenses {
+		if key, found := findLicense(lic); found {
+			repo.License = key
+
+			break
+		}
+	}
+
+	if query.unchanged(repo) {
+		return repo, nil, nil
+	}
+
+	endpoint := "/tags"
+	if query.versionSource == k6registry.VersionSourceReleases {
+		endpoint = "/releases"
+	}
+
+	var tags []string
+
+	for page := 1; ; page++ {
+		var refs []giteaRef
+
+		params := "?limit=" + strconv.Itoa(giteaPageSize) + "&page=" + strconv.Itoa(page)
+
+		if err := getJSON(ctx, p.client, base+endpoint+params, &refs); err != nil {
+			return nil, nil, err
+		}
+
+		for _, ref := range refs {
+			if ref.Draft || (query.skipPrereleases && ref.Prerelease) {
+				continue
+			}
+
+			tags = append(tags, ref.tagName())
+		}
+
+		// The page size can be capped below the requested limit by the server
+		// (MAX_RESPONSE_ITEMS), so the listing ends with the first empty page.
+		if len(refs) == 0 {
+			break
+		}
+	}
+
+	return repo, tags, nil
+}
@@ -0,0 +1,162 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+// newTestGiteaServer returns a fake Gitea API server with tagCount tags,
+// returning at most maxLimit items per page.
+func newTestGiteaServer(t *testing.T, tagCount int, maxLimit int) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/repos/szkiba/xk6-codename", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{
+  "name": "xk6-codename",
+  "owner": {"login": "szkiba"},
+  "description": "Generate random, pronounceable codenames",
+  "website": "",
+  "html_url": "https://codeberg.org/szkiba/xk6-codename",
+  "clone_url": "https://codeberg.org/szkiba/xk6-codename.git",
+  "stars_count": 3,
+  "archived": false,
+  "private": false,
+  "topics": ["xk6"],
+  "licenses": ["mit"],
+  "updated_at": "2024-09-10T10:00:00Z"
+}`)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/szkiba/xk6-codename/tags", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		limit = min(limit, maxLimit)
+
+		tags := []string{}
+
+		for idx := (page - 1) * limit; idx < page*limit && idx < tagCount; idx++ {
+			tags = append(tags, fmt.Sprintf(`{"name":"v0.%d.0"}`, idx))
+		}
+
+		_, _ = fmt.Fprintf(w, "[%s]", strings.Join(tags, ","))
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/szkiba/xk6-codename/releases", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = fmt.Fprint(w, `[]`)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `[{"name": "First release", "tag_name": "v0.1.0"}]`)
+	})
+
+	srv := httptest.NewServer(mux)
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestGiteaProvider(t *testing.T) {
+	t.Parallel()
+
+	const tagCount = giteaPageSize + 5
+
+	srv := newTestGiteaServer(t, tagCount, giteaPageSize)
+	provider := newGiteaProvider(cbModulePrefix, srv.URL+"/", "")
+
+	if !provider.match("codeberg.org/szkiba/xk6-codename") {
+		t.Fatal("expected provider to match codeberg.org module")
+	}
+
+	repo, tags, err := provider.load(context.Background(), repositoryQuery{module: "codeberg.org/szkiba/xk6-codename"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Name != "xk6-codename" || repo.Owner != "szkiba" {
+		t.Errorf("got owner/name %s/%s", repo.Owner, repo.Name)
+	}
+
+	if repo.License != "MIT" {
+		t.Errorf("got license %q, want %q", repo.License, "MIT")
+	}
+
+	if repo.Homepage != repo.URL {
+		t.Errorf("got homepage %q, want %q", repo.Homepage, repo.URL)
+	}
+
+	if !repo.Public || repo.Stars != 3 || repo.Timestamp != 1725962400 {
+		t.Errorf("unexpected repository metadata %+v", repo)
+	}
+
+	if !slices.Equal(repo.Topics, []string{"xk6"}) {
+		t.Errorf("got topics %v", repo.Topics)
+	}
+
+	if len(tags) != tagCount {
+		t.Errorf("got %d tags, want %d", len(tags), tagCount)
+	}
+}
+
+func TestGiteaProvider_Releases(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestGiteaServer(t, 0, giteaPageSize)
+	provider := newGiteaProvider(cbModulePrefix, srv.URL, "")
+
+	_, tags, err := provider.load(context.Background(), repositoryQuery{
+		module:        "codeberg.org/szkiba/xk6-codename",
+		versionSource: k6registry.VersionSourceReleases,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.1.0"}; !slices.Equal(tags, want) {
+		t.Fatalf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestGiteaProvider_SmallPages(t *testing.T) {
+	t.Parallel()
+
+	// a self-hosted instance with MAX_RESPONSE_ITEMS below the requested page size
+	const tagCount = 25
+
+	srv := newTestGiteaServer(t, tagCount, 10)
+	provider := newGiteaProvider(cbModulePrefix, srv.URL, "")
+
+	_, tags, err := provider.load(context.Background(), repositoryQuery{module: "codeberg.org/szkiba/xk6-codename"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != tagCount {
+		t.Errorf("got %d tags, want %d", len(tags), tagCount)
+	}
+}
+
+func TestGiteaProvider_NotFound(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestGiteaServer(t, 0, giteaPageSize)
+	provider := newGiteaProvider(cbModulePrefix, srv.URL, "")
+
+	_, _, err := provider.load(context.Background(), repositoryQuery{module: "codeberg.org/szkiba/xk6-missing"})
+	if !errors.Is(err, errRequestFailed) {
+		t.Fatalf("got error %v, want %v", err, errRequestFailed)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/grafana/k6registry"
+)
+
+const (
+	ghModulePrefix = "github.com/"
+
+	githubPageSize = 100
+)
+
+// githubProvider loads repository metadata using the GitHub API.
+type githubProvider struct {
+	prefix string
+	client *github.Client
+}
+
+// newGitHubProvider returns a provider for modules starting with prefix.
+// If client is nil, the GitHub client from the context will be used.
+func newGitHubProvider(prefix string, client *github.Client) *githubProvider {
+	return &githubProvider{prefix: prefix, client: client}
+}
+
+// newGitHubEnterpriseClient returns a client for the GitHub Enterprise Server instance at baseURL.
+// If token is empty, the token of the GitHub CLI will be used for the host of baseURL.
+func newGitHubEnterpriseClient(baseURL string, token string) (*github.Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	htc, err := newHTTPClient(u.Hostname(), token, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return github.NewClient(github.WithHTTPClient(htc), github.WithEnterpriseURLs(baseURL, baseURL))
+}
+
+func (p *githubProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+func (p *githubProvider) load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	module := query.module
+
+	slog.Debug("Loading GitHub repository", "module", module) //nolint:gosec // debug log
+
+	client := p.client
+
+	if client == nil {
+		var err error
+
+		client, err = contextGitHubClient(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	owner, name := moduleToOwnerAndName(p.prefix, module)
+
+	repo := new(k6registry.Repository)
+
+	rep, _, err := client.Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo.Topics = rep.Topics
+
+	repo.URL = rep.GetHTMLURL()
+	repo.Name = rep.GetName()
+	repo.Owner = rep.GetOwner().GetLogin()
+
+	repo.Homepage = rep.GetHomepage()
+	if len(repo.Homepage) == 0 {
+		repo.Homepage = repo.URL
+	}
+
+	repo.Archived = rep.GetArchived()
+
+	repo.Description = rep.GetDescription()
+	repo.Stars = rep.GetStargazersCount()
+
+	if lic := rep.GetLicense(); lic != nil {
+		repo.License = lic.GetSPDXID()
+	}
+
+	repo.Public = rep.GetVisibility() == "public"
+
+	if ts := rep.GetPushedAt(); !ts.IsZero() {
+		repo.Timestamp = float64(ts.Unix())
+	}
+
+	repo.CloneURL = rep.GetCloneURL()
+
+	if query.unchanged(repo) {
+		return repo, nil, nil
+	}
+
+	var tags []string
+
+	if query.versionSource == k6registry.VersionSourceReleases {
+		tags, err = githubReleases(ctx, client, owner, name, query.skipPrereleases)
+	} else {
+		tags, err = githubTags(ctx, client, owner, name)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, tags, nil
+}
+
+// githubTags returns all tag names of the repository.
+func githubTags(ctx context.Context, client *github.Client, owner string, name string) ([]string, error) {
+	var tags []string
+
+	opts := &github.ListOptions{PerPage: githubPageSize}
+
+	for {
+		repoTags, resp, err := client.Repositories.ListTags(ctx, owner, name, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range repoTags {
+			tags = append(tags, tag.GetName())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return tags, nil
+}
+
+// githubReleases returns the tag names of the published releases of the repository.
+// Releases marked as prerelease are skipped if skipPrereleases is true.
+func githubReleases(
+	ctx context.Context,
+	client *github.Client,
+	owner string,
+	name string,
+	skipPrereleases bool,
+) ([]string, error) {
+	var tags []string
+
+	opts := &github.ListOptions{PerPage: githubPageSize}
+
+	for {
+		rels, resp, err := client.Repositories.ListReleases(ctx, owner, name, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rel := range rels {
+			if rel.GetDraft() || (skipPrereleases && rel.GetPrerelease()) {
+				continue
+			}
+
+			tags = append(tags, rel.GetTagName())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,153 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/grafana/k6registry"
+)
+
+func newTestGitHubClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+
+	t.Cleanup(srv.Close)
+
+	client, err := github.NewClient(github.WithEnterpriseURLs(srv.URL, srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestGitHubProvider_Pagination(t *testing.T) {
+	t.Parallel()
+
+	const (
+		pages   = 3
+		perPage = 2
+	)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v3/repos/grafana/xk6-foo", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"name": "xk6-foo", "owner": {"login": "grafana"}, "visibility": "public"}`)
+	})
+
+	mux.HandleFunc("GET /api/v3/repos/grafana/xk6-foo/tags", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		if page < pages {
+			next := *r.URL
+			query := next.Query()
+			query.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = query.Encode()
+
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+		}
+
+		_, _ = fmt.Fprintf(w, `[{"name": "v0.%d.0"}, {"name": "v0.%d.1"}]`, page, page)
+	})
+
+	provider := newGitHubProvider(ghModulePrefix, newTestGitHubClient(t, mux))
+
+	repo, tags, err := provider.load(context.Background(), repositoryQuery{module: "github.com/grafana/xk6-foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !repo.Public || repo.Owner != "grafana" {
+		t.Errorf("unexpected repository metadata %+v", repo)
+	}
+
+	if len(tags) != pages*perPage {
+		t.Fatalf("got tags %v, want %d entries", tags, pages*perPage)
+	}
+}
+
+func TestGitHubProvider_Releases(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v3/repos/grafana/xk6-foo", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"name": "xk6-foo", "owner": {"login": "grafana"}}`)
+	})
+
+	mux.HandleFunc("GET /api/v3/repos/grafana/xk6-foo/releases", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `[
+  {"tag_name": "v1.1.0", "draft": true},
+  {"tag_name": "v1.1.0-rc.1", "prerelease": true},
+  {"tag_name": "v1.0.0"},
+  {"tag_name": "v0.9.0"}
+]`)
+	})
+
+	provider := newGitHubProvider(ghModulePrefix, newTestGitHubClient(t, mux))
+
+	query := repositoryQuery{
+		module:        "github.com/grafana/xk6-foo",
+		versionSource: k6registry.VersionSourceReleases,
+	}
+
+	_, tags, err := provider.load(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v1.1.0-rc.1", "v1.0.0", "v0.9.0"}; !slices.Equal(tags, want) {
+		t.Fatalf("got tags %v, want %v", tags, want)
+	}
+
+	query.skipPrereleases = true
+
+	_, tags, err = provider.load(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v1.0.0", "v0.9.0"}; !slices.Equal(tags, want) {
+		t.Fatalf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestGitHubProvider_Unchanged(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v3/repos/grafana/xk6-foo", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"name": "xk6-foo", "owner": {"login": "grafana"}, "pushed_at": "2024-09-10T10:00:00Z"}`)
+	})
+
+	mux.HandleFunc("GET /api/v3/repos/grafana/xk6-foo/tags", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("tags of an unchanged repository should not be listed")
+
+		_, _ = fmt.Fprint(w, `[]`)
+	})
+
+	provider := newGitHubProvider(ghModulePrefix, newTestGitHubClient(t, mux))
+
+	repo, tags, err := provider.load(context.Background(), repositoryQuery{
+		module:    "github.com/grafana/xk6-foo",
+		timestamp: 1725962400,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Timestamp != 1725962400 || tags != nil {
+		t.Fatalf("got timestamp %v and tags %v, want unchanged repository", repo.Timestamp, tags)
+	}
+}
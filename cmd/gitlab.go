@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/grafana/k6registry"
+	"github.com/hashicorp/go-retryablehttp"
+	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const glModulePrefix = "gitlab.com/"
+
+const (
+	glHost = "gitlab.com"
+
+	gitlabPageSize = 100
+
+	gitlabRetryMax     = 8
+	gitlabRetryWaitMin = time.Second
+	gitlabRetryWaitMax = time.Minute
+
+	headerRateLimitReset = "RateLimit-Reset"
+)
+
+// gitlabTokenEnvs contains the environment variables checked for a GitLab token, in glab CLI order.
+var gitlabTokenEnvs = []string{"GITLAB_TOKEN", "GITLAB_ACCESS_TOKEN", "OAUTH_TOKEN"} //nolint:gochecknoglobals
+
+// gitlabProvider loads repository metadata using the GitLab API.
+type gitlabProvider struct {
+	prefix  string
+	baseURL string
+	token   string
+
+	clientOnce sync.Once
+	client     *gitlab.Client
+	clientErr  error
+}
+
+// newGitLabProvider returns a provider for modules starting with prefix,
+// served by the GitLab instance at baseURL (gitlab.com if empty).
+// If token is empty, it will be discovered like the glab CLI does.
+func newGitLabProvider(prefix string, baseURL string, token string) *gitlabProvider {
+	return &gitlabProvider{prefix: prefix, baseURL: baseURL, token: token}
+}
+
+func (p *gitlabProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+// getClient returns the GitLab client of the provider, creating it on first use.
+// The client is shared, so its rate limiter applies to all modules of the provider.
+func (p *gitlabProvider) getClient() (*gitlab.Client, error) {
+	p.clientOnce.Do(func() {
+		host := glHost
+
+		opts := []gitlab.ClientOptionFunc{
+			gitlab.WithCustomRetryMax(gitlabRetryMax),
+			gitlab.WithCustomRetryWaitMinMax(gitlabRetryWaitMin, gitlabRetryWaitMax),
+			gitlab.WithCustomBackoff(gitlabBackoff),
+			gitlab.WithHTTPClient(&http.Client{Transport: newConditionalTransport(nil)}),
+		}
+
+		if len(p.baseURL) > 0 {
+			u, err := url.Parse(p.baseURL)
+			if err != nil {
+				p.clientErr = err
+
+				return
+			}
+
+			host = u.Hostname()
+
+			opts = append(opts, gitlab.WithBaseURL(p.baseURL))
+		}
+
+		token := p.token
+		if len(token) == 0 {
+			token = gitlabTokenForHost(host)
+		}
+
+		if len(token) == 0 {
+			slog.Debug("No GitLab token found, using unauthenticated requests", "host", host)
+		}
+
+		p.client, p.clientErr = gitlab.NewClient(token, opts...)
+	})
+
+	return p.client, p.clientErr
+}
+
+func (p *gitlabProvider) load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	module := query.module
+
+	slog.Debug("Loading GitLab repository", "module", module) //nolint:gosec // debug log
+
+	client, err := p.getClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pid := strings.Trim(strings.TrimPrefix(module, p.prefix), "/")
+
+	lic := true
+
+	proj, _, err := client.Projects.GetProject(pid, &gitlab.GetProjectOptions{License: &lic}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo := new(k6registry.Repository)
+
+	repo.Owner = proj.Namespace.FullPath
+	repo.Name = proj.Name
+	repo.Description = proj.Description
+	repo.Stars = int(proj.StarCount)
+	repo.Archived = proj.Archived
+	repo.URL = proj.WebURL
+	repo.Homepage = proj.WebURL
+	repo.Topics = proj.Topics
+	repo.Public = len(proj.Visibility) == 0 || proj.Visibility == gitlab.PublicVisibility
+
+	repo.CloneURL = proj.HTTPURLToRepo
+
+	if proj.LastActivityAt != nil {
+		repo.Timestamp = float64(proj.LastActivityAt.Unix())
+	}
+
+	if proj.License != nil {
+		repo.License, _ = findLicense(proj.License.Key)
+	}
+
+	// The last activity time is updated at most once an hour, so it does not reflect
+	// a recently created tag or release; the tags are always listed.
+	var tags []string
+
+	if query.versionSource == k6registry.VersionSourceTags {
+		tags, err = gitlabTags(ctx, client, pid)
+	} else {
+		tags, err = gitlabReleases(ctx, client, pid)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, tags, nil
+}
+
+// gitlabReleases returns the tag names of all releases of the project.
+func gitlabReleases(ctx context.Context, client *gitlab.Client, pid string) ([]string, error) {
+	var tags []string
+
+	opts := &gitlab.ListReleasesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: gitlabPageSize},
+	}
+
+	for {
+		rels, resp, err := client.Releases.ListReleases(pid, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rel := range rels {
+			tags = append(tags, rel.TagName)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return tags, nil
+}
+
+// gitlabTags returns all tag names of the project.
+func gitlabTags(ctx context.Context, client *gitlab.Client, pid string) ([]string, error) {
+	var tags []string
+
+	opts := &gitlab.ListTagsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: gitlabPageSize},
+	}
+
+	for {
+		repoTags, resp, err := client.Tags.ListTags(pid, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range repoTags {
+			tags = append(tags, tag.Name)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return tags, nil
+}
+
+// gitlabBackoff returns the time to wait before retrying a request.
+// Rate limited (429) requests wait until the time given in the RateLimit-Reset header,
+// otherwise the Retry-After header or an exponential backoff is used.
+func gitlabBackoff(minWait, maxWait time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if reset, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil && reset > 0 {
+			wait := min(max(time.Until(time.Unix(reset, 0)), 0), maxWait)
+
+			slog.Debug("GitLab rate limit exceeded", "wait", wait, "attempt", attemptNum)
+
+			return wait
+		}
+	}
+
+	return retryablehttp.DefaultBackoff(minWait, maxWait, attemptNum, resp)
+}
+
+// gitlabTokenForHost returns the GitLab token for host, looked up the same way as the glab CLI:
+// from the environment variables first, then from the glab CLI config file.
+// The environment variables are only used for the default host (gitlab.com, or GITLAB_HOST if set),
+// so their token is never sent to other GitLab instances.
+func gitlabTokenForHost(host string) string {
+	if host == gitlabDefaultHost() {
+		for _, name := range gitlabTokenEnvs {
+			if token := os.Getenv(name); len(token) > 0 { //nolint:forbidigo // CLI tool
+				return token
+			}
+		}
+	}
+
+	dir := os.Getenv("GLAB_CONFIG_DIR") //nolint:forbidigo // CLI tool
+	if len(dir) == 0 {
+		dir = filepath.Join(xdg.ConfigHome, "glab-cli")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.yml")) //nolint:gosec,forbidigo // glab config
+	if err != nil {
+		return ""
+	}
+
+	var cfg struct {
+		Hosts map[string]struct {
+			Token string `yaml:"token"`
+		} `yaml:"hosts"`
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		slog.Debug("Invalid glab config", "error", err)
+
+		return ""
+	}
+
+	return cfg.Hosts[host].Token
+}
+
+// gitlabDefaultHost returns the host name of the GITLAB_HOST environment variable (a host name or a URL),
+// or gitlab.com if it is not set.
+func gitlabDefaultHost() string {
+	host := os.Getenv("GITLAB_HOST") //nolint:forbidigo // CLI tool
+	if len(host) == 0 {
+		return glHost
+	}
+
+	if u, err := url.Parse(host); err == nil && len(u.Hostname()) > 0 {
+		return u.Hostname()
+	}
+
+	return host
+}
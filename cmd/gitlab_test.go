@@ -0,0 +1,243 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+func newTestGitLabServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Private-Token") != token {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return false
+		}
+
+		return true
+	}
+
+	mux.HandleFunc("GET /api/v4/projects/{pid}", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+
+		if r.PathValue("pid") != "k6/tools/xk6-foo" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `{
+  "name": "xk6-foo",
+  "description": "Foo extension",
+  "namespace": {"full_path": "k6/tools"},
+  "star_count": 7,
+  "web_url": "https://git.example.com/k6/tools/xk6-foo",
+  "http_url_to_repo": "https://git.example.com/k6/tools/xk6-foo.git",
+  "visibility": "internal",
+  "last_activity_at": "2024-09-10T10:00:00Z",
+  "license": {"key": "apache-2.0"}
+}`)
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/{pid}/releases", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = fmt.Fprint(w, `[{"tag_name": "v0.1.0"}]`)
+
+			return
+		}
+
+		w.Header().Set("X-Next-Page", "2")
+
+		_, _ = fmt.Fprint(w, `[{"tag_name": "v0.2.0"}]`)
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/{pid}/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `[{"name": "v0.2.0"}, {"name": "v0.1.1"}, {"name": "v0.1.0"}]`)
+	})
+
+	srv := httptest.NewServer(mux)
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestGitLabProvider_SelfHosted(t *testing.T) {
+	t.Parallel()
+
+	const token = "secret"
+
+	srv := newTestGitLabServer(t, token)
+	provider := newGitLabProvider("git.example.com/", srv.URL, token)
+
+	repo, tags, err := provider.load(context.Background(), repositoryQuery{module: "git.example.com/k6/tools/xk6-foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Owner != "k6/tools" || repo.Name != "xk6-foo" || repo.License != "Apache-2.0" {
+		t.Errorf("unexpected repository metadata %+v", repo)
+	}
+
+	if repo.Public {
+		t.Error("expected internal project not to be public")
+	}
+
+	if want := []string{"v0.2.0", "v0.1.0"}; !slices.Equal(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestGitLabProvider_Tags(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestGitLabServer(t, "")
+	provider := newGitLabProvider("git.example.com/", srv.URL, "")
+
+	// the tags are listed even if the last activity time has not changed
+	_, tags, err := provider.load(context.Background(), repositoryQuery{
+		module:        "git.example.com/k6/tools/xk6-foo",
+		versionSource: k6registry.VersionSourceTags,
+		timestamp:     1725962400,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.2.0", "v0.1.1", "v0.1.0"}; !slices.Equal(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+}
+
+func TestGitLabProvider_RateLimited(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `{"name": "xk6-foo", "namespace": {"full_path": "k6"}}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	provider := newGitLabProvider("git.example.com/", srv.URL, "secret")
+
+	client, err := provider.getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proj, _, err := client.Projects.GetProject("k6/xk6-foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if proj.Name != "xk6-foo" || calls.Load() != 2 {
+		t.Fatalf("got project %q after %d calls, want a retry", proj.Name, calls.Load())
+	}
+}
+
+func TestGitLabBackoff(t *testing.T) {
+	t.Parallel()
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: make(http.Header)}
+	resp.Header.Set(headerRateLimitReset, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+
+	if got := gitlabBackoff(time.Second, time.Minute, 1, resp); got != time.Minute {
+		t.Errorf("got wait %v, want it capped to %v", got, time.Minute)
+	}
+
+	resp.Header.Set(headerRateLimitReset, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+
+	if got := gitlabBackoff(time.Second, time.Minute, 1, resp); got != 0 {
+		t.Errorf("got wait %v for past reset time, want 0", got)
+	}
+
+	resp.Header.Del(headerRateLimitReset)
+	resp.Header.Set("Retry-After", "3")
+
+	if got := gitlabBackoff(time.Second, time.Minute, 1, resp); got != 3*time.Second {
+		t.Errorf("got wait %v, want Retry-After value", got)
+	}
+}
+
+func TestGitLabTokenForHost(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFileT(t, dir, "config.yml", `
+hosts:
+  gitlab.com:
+    token: glab-token
+  git.example.com:
+    token: glab-example-token
+`)
+
+	for _, name := range gitlabTokenEnvs {
+		t.Setenv(name, "")
+	}
+
+	t.Setenv("GLAB_CONFIG_DIR", dir)
+
+	if got := gitlabTokenForHost("git.example.com"); got != "glab-example-token" {
+		t.Errorf("got token %q from glab config", got)
+	}
+
+	if got := gitlabTokenForHost("git.other.com"); got != "" {
+		t.Errorf("got token %q for unknown host", got)
+	}
+
+	t.Setenv("GITLAB_HOST", "")
+	t.Setenv("GITLAB_ACCESS_TOKEN", "env-token")
+
+	if got := gitlabTokenForHost(glHost); got != "env-token" {
+		t.Errorf("got token %q, want the environment variable to take precedence", got)
+	}
+
+	// the token of the environment variables is not sent to other hosts
+	if got := gitlabTokenForHost("git.example.com"); got != "glab-example-token" {
+		t.Errorf("got token %q, want the glab config token of the host", got)
+	}
+
+	if got := gitlabTokenForHost("git.other.com"); got != "" {
+		t.Errorf("got token %q for unknown host", got)
+	}
+
+	t.Setenv("GITLAB_HOST", "https://git.other.com")
+
+	if got := gitlabTokenForHost("git.other.com"); got != "env-token" {
+		t.Errorf("got token %q, want the environment variable for GITLAB_HOST", got)
+	}
+
+	if got := gitlabTokenForHost(glHost); got != "glab-token" {
+		t.Errorf("got token %q, want the glab config token of gitlab.com", got)
+	}
+}
@@ -74,7 +74,8 @@ func loadCompliance(ctx context.Context, module string, version string, timestam
 
 	age := time.Now().Unix() - comp.Timestamp
 
-	if comp.Timestamp >= timestamp && age <= complianceCacheTTL {
+	// the cached results never expire in offline mode
+	if comp.Timestamp >= timestamp && (age <= complianceCacheTTL || contextOffline(ctx)) {
 		return &comp, true, nil
 	}
 
@@ -120,6 +121,10 @@ func checkCompliance(
 		return nil, err
 	}
 
+	if contextOffline(ctx) {
+		return nil, fmt.Errorf("%w: %s@%s: compliance", errOffline, module, version)
+	}
+
 	base, err := modulesDir(ctx)
 	if err != nil {
 		return nil, err
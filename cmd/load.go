@@ -6,23 +6,50 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
-	"github.com/google/go-github/v88/github"
 	"github.com/grafana/k6registry"
-	gitlab "gitlab.com/gitlab-org/api/client-go/v2"
 	"gopkg.in/yaml.v3"
 )
 
-var errCompliance = errors.New("compliance check failed")
+var (
+	errCompliance        = errors.New("compliance check failed")
+	errInvalidPrerelease = errors.New("invalid prerelease policy")
+)
 
 type loadOptions struct {
 	lint             bool
 	ignoreLintErrors bool
 	lintChecks       []string
+	parallel         int
+	lintParallel     int
+
+	maxVersionsPerMajor int
+
+	// default source of the versions, the provider specific default if empty
+	versionSource k6registry.VersionSource
+
+	// default prerelease version policy, include if empty
+	prerelease k6registry.Prerelease
+
+	// extensions of the previous generation by module path, reused if their repository is unchanged
+	previous map[string]*k6registry.Extension
+}
+
+// previousExtension returns the extension of the previous generation with a known repository timestamp,
+// or nil if there is none.
+func previousExtension(ext *k6registry.Extension, opts loadOptions) *k6registry.Extension {
+	prev, found := opts.previous[ext.Module]
+	if !found || prev.Repo == nil || prev.Repo.Timestamp == 0 {
+		return nil
+	}
+
+	return prev
 }
 
 // isK6Module reports whether module is any major version of the k6 module
@@ -91,26 +118,47 @@ func loadSource(in io.Reader) (k6registry.Registry, error) {
 		registry = append(registry, k6AsExtension())
 	}
 
+	slog.Debug("Validate semantics")
+
+	if err := validateSemantics(registry, raw); err != nil {
+		return nil, err
+	}
+
 	return registry, nil
 }
 
-func loadOne(ctx context.Context, ext *k6registry.Extension, lint bool, checks []string) error {
+func loadOne(ctx context.Context, ext *k6registry.Extension, opts loadOptions) error {
 	if len(ext.Tier) == 0 {
 		ext.Tier = k6registry.TierCommunity
 	}
 
-	repo, tags, err := loadRepository(ctx, ext)
+	repo, tags, err := loadRepository(ctx, ext, opts)
 	if err != nil {
 		return err
 	}
 
 	ext.Repo = repo
 
+	// The compliance results of an unchanged repository are reused.
+	// The versions are always selected again from the tags, the selection settings may have changed.
+	var previousCompliance k6registry.ExtensionCompliance
+
+	if prev := previousExtension(ext, opts); prev != nil && repo.Timestamp == prev.Repo.Timestamp {
+		slog.Debug("Reuse previous compliance results", "module", ext.Module) //nolint:gosec // debug log
+
+		previousCompliance = prev.Compliance
+	}
+
 	if len(ext.Versions) == 0 {
 		ext.Versions = tagsToVersions(tags)
 	}
 
-	if !lint || ext.Module == k6Module {
+	// The versions are selected before linting, so the dropped versions are not linted.
+	if err := selectVersions(ext, opts); err != nil {
+		return err
+	}
+
+	if !opts.lint || ext.Module == k6Module {
 		return nil
 	}
 
@@ -118,86 +166,154 @@ func loadOne(ctx context.Context, ext *k6registry.Extension, lint bool, checks [
 		ext.Compliance = make(k6registry.ExtensionCompliance)
 	}
 
-	complianceErrors := []error{}
+	checks := enabledChecks(opts.lintChecks)
 
-	for _, version := range ext.Versions {
-		compliance, err := checkCompliance(
-			ctx,
-			ext.Module,
-			version,
-			checks,
-			repo.CloneURL,
-			int64(repo.Timestamp),
-		)
-		if err != nil {
-			return err
-		}
+	// Indexed by version position, ext.Compliance is only updated after all checks are done.
+	compliances := make([]k6registry.Compliance, len(ext.Versions))
+	complianceErrors := make([]error, len(ext.Versions))
 
-		var issues []string
+	err = runParallel(ctx, len(ext.Versions), opts.lintParallel, func(ctx context.Context, idx int) error {
+		version := ext.Versions[idx]
 
-		for _, check := range compliance.Checks {
-			if !check.Passed {
-				issues = append(issues, check.ID)
-			}
+		issues, err := complianceIssues(ctx, ext.Module, version, repo, checks, previousCompliance)
+		if err != nil {
+			return err
 		}
 
 		if len(issues) > 0 {
-			complianceErrors = append(complianceErrors, fmt.Errorf("%w %s@%s", errCompliance, ext.Module, version))
+			complianceErrors[idx] = fmt.Errorf("%w %s@%s", errCompliance, ext.Module, version)
 		}
 
-		ext.Compliance[version] = k6registry.Compliance{
+		compliances[idx] = k6registry.Compliance{
+			Checks: checks,
 			Issues: issues,
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for idx, version := range ext.Versions {
+		ext.Compliance[version] = compliances[idx]
 	}
 
 	return errors.Join(complianceErrors...)
 }
 
+// enabledChecks returns the sorted IDs of the enabled compliance checks, nil if all checks are enabled.
+func enabledChecks(checks []string) []string {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	checks = slices.Clone(checks)
+
+	slices.Sort(checks)
+
+	return slices.Compact(checks)
+}
+
+// complianceIssues returns the IDs of the failed compliance checks of the given version.
+// The previous compliance result is used if available and made with the same checks.
+func complianceIssues(
+	ctx context.Context,
+	module string,
+	version string,
+	repo *k6registry.Repository,
+	checks []string,
+	previous k6registry.ExtensionCompliance,
+) ([]string, error) {
+	if prev, found := previous[version]; found && slices.Equal(prev.Checks, checks) {
+		return prev.Issues, nil
+	}
+
+	compliance, err := checkCompliance(
+		ctx,
+		module,
+		version,
+		checks,
+		repo.CloneURL,
+		int64(repo.Timestamp),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []string
+
+	for _, check := range compliance.Checks {
+		if !check.Passed {
+			issues = append(issues, check.ID)
+		}
+	}
+
+	return issues, nil
+}
+
 func load(
 	ctx context.Context,
 	in io.Reader,
 	opts loadOptions,
 ) (k6registry.Registry, error) {
+	switch opts.versionSource {
+	case "", k6registry.VersionSourceTags, k6registry.VersionSourceReleases:
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedVersionSource, opts.versionSource)
+	}
+
+	switch opts.prerelease {
+	case "", k6registry.PrereleaseInclude, k6registry.PrereleaseExclude, k6registry.PrereleaseLatestOnly:
+	default:
+		return nil, fmt.Errorf("%w: %s", errInvalidPrerelease, opts.prerelease)
+	}
+
 	registry, err := loadSource(in)
 	if err != nil {
 		return nil, err
 	}
 
-	compliancedErrors := []error{}
+	// Indexed by extension position, so errors are reported in source order
+	// regardless of the completion order of the concurrent workers.
+	complianceErrors := make([]error, len(registry))
 
-	for idx := range registry {
+	// The extensions without cached data are collected, so all of them are reported in offline mode.
+	offlineErrors := make([]error, len(registry))
+
+	err = runParallel(ctx, len(registry), opts.parallel, func(ctx context.Context, idx int) error {
 		ext := &registry[idx]
 
 		slog.Debug("Process extension", "module", ext.Module) //nolint:gosec // debug log
 
-		err := loadOne(ctx, ext, opts.lint, opts.lintChecks)
+		err := loadOne(ctx, ext, opts)
 		if err != nil {
-			if !errors.Is(err, errCompliance) {
-				return nil, err
+			switch {
+			case errors.Is(err, errCompliance):
+				complianceErrors[idx] = err
+			case errors.Is(err, errOffline):
+				offlineErrors[idx] = err
+			default:
+				return err
 			}
-
-			compliancedErrors = append(compliancedErrors, err)
 		}
 
-		if len(ext.Constraints) > 0 {
-			constraints, err := semver.NewConstraint(ext.Constraints)
-			if err != nil {
-				return nil, err
-			}
-
-			ext.Versions = filterVersions(ext.Versions, constraints)
-		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if err := sortVersions(ext.Versions); err != nil {
-			return nil, err
-		}
+	if err := errors.Join(offlineErrors...); err != nil {
+		return nil, err
 	}
 
-	if len(compliancedErrors) == 0 {
+	complianceErr := errors.Join(complianceErrors...)
+	if complianceErr == nil {
 		return registry, nil
 	}
 
-	slog.Warn(errors.Join(compliancedErrors...).Error()) //nolint:gosec // CLI warning output
+	slog.Warn(complianceErr.Error()) //nolint:gosec // CLI warning output
 
 	if opts.ignoreLintErrors {
 		return registry, nil
@@ -206,179 +322,160 @@ func load(
 	return registry, errCompliance
 }
 
-func loadRepository(ctx context.Context, ext *k6registry.Extension) (*k6registry.Repository, []string, error) {
-	module := ext.Module
-
-	if ext.Repo != nil && len(ext.Repo.CloneURL) > 0 {
-		versions, err := loadGit(ctx, module, ext.Repo.CloneURL)
+// selectVersions applies the version constraints, the prerelease policy and the per major version limit
+// to the versions of the extension and sorts them in descending order.
+func selectVersions(ext *k6registry.Extension, opts loadOptions) error {
+	if len(ext.Constraints) > 0 {
+		constraints, err := semver.NewConstraint(ext.Constraints)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
 
-		return ext.Repo, versions, nil
+		ext.Versions = filterVersions(ext.Versions, constraints)
 	}
 
-	if strings.HasPrefix(module, k6Module) || strings.HasPrefix(module, ghModulePrefix) {
-		repo, tags, err := loadGitHub(ctx, module)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		// Some unused metadata in the k6 repository changes too often
-		if strings.HasPrefix(module, k6Module) {
-			repo.Stars = 0
-			repo.Timestamp = 0
-			repo.CloneURL = ""
-		}
+	ext.Versions = filterPrereleases(ext.Versions, prereleasePolicy(ext, opts))
 
-		return repo, tags, nil
+	if err := sortVersions(ext.Versions); err != nil {
+		return err
 	}
 
-	if strings.HasPrefix(module, glModulePrefix) {
-		repo, tags, err := loadGitLab(ctx, module)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		return repo, tags, nil
-	}
+	ext.Versions = limitVersionsPerMajor(ext.Versions, opts.maxVersionsPerMajor)
 
-	return nil, nil, fmt.Errorf("%w: %s", errUnsupportedModule, module)
+	return nil
 }
 
-func moduleToOwnerAndName(module string) (string, string) {
-	if isK6Module(module) {
-		return "grafana", "k6"
+// prereleasePolicy returns the prerelease policy of the extension, or the default policy if not set.
+func prereleasePolicy(ext *k6registry.Extension, opts loadOptions) k6registry.Prerelease {
+	if len(ext.Prerelease) > 0 {
+		return ext.Prerelease
 	}
 
-	const maxParts = 4
-
-	parts := strings.SplitN(module, "/", maxParts)
+	if len(opts.prerelease) > 0 {
+		return opts.prerelease
+	}
 
-	return parts[1], parts[2]
+	return k6registry.PrereleaseInclude
 }
 
-func loadGitHub(ctx context.Context, module string) (*k6registry.Repository, []string, error) {
-	slog.Debug("Loading GitHub repository", "module", module) //nolint:gosec // debug log
+func loadRepository(
+	ctx context.Context,
+	ext *k6registry.Extension,
+	opts loadOptions,
+) (*k6registry.Repository, []string, error) {
+	module := ext.Module
 
-	client, err := contextGitHubClient(ctx)
-	if err != nil {
-		return nil, nil, err
+	source := ext.VersionSource
+	if len(source) == 0 {
+		source = opts.versionSource
 	}
 
-	owner, name := moduleToOwnerAndName(module)
-
-	repo := new(k6registry.Repository)
+	// Only the releases requested by the extension itself are required,
+	// the global default falls back to tags where there are no releases.
+	explicitReleases := ext.VersionSource == k6registry.VersionSourceReleases
 
-	rep, _, err := client.Repositories.Get(ctx, owner, name)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	repo.Topics = rep.Topics
+	if ext.Repo != nil && len(ext.Repo.CloneURL) > 0 {
+		if explicitReleases {
+			return nil, nil, fmt.Errorf("%w: %s: releases are not available using clone_url",
+				errUnsupportedVersionSource, module)
+		}
 
-	repo.URL = rep.GetHTMLURL()
-	repo.Name = rep.GetName()
-	repo.Owner = rep.GetOwner().GetLogin()
+		versions, err := loadGit(ctx, module, ext.Repo.CloneURL)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	repo.Homepage = rep.GetHomepage()
-	if len(repo.Homepage) == 0 {
-		repo.Homepage = repo.URL
+		return ext.Repo, versions, nil
 	}
 
-	repo.Archived = rep.GetArchived()
-
-	repo.Description = rep.GetDescription()
-	repo.Stars = rep.GetStargazersCount()
-
-	if lic := rep.GetLicense(); lic != nil {
-		repo.License = lic.GetSPDXID()
+	query := repositoryQuery{
+		module:          module,
+		versionSource:   source,
+		defaultSource:   len(ext.VersionSource) == 0,
+		skipPrereleases: prereleasePolicy(ext, opts) == k6registry.PrereleaseExclude,
 	}
 
-	repo.Public = rep.GetVisibility() == "public"
+	offline := contextOffline(ctx)
 
-	if ts := rep.GetPushedAt(); !ts.IsZero() {
-		repo.Timestamp = float64(ts.Unix())
+	// The tags of an unchanged repository are taken from the repository cache instead of listing them.
+	if prev := previousExtension(ext, opts); prev != nil && !offline {
+		query.timestamp = prev.Repo.Timestamp
 	}
 
-	repo.CloneURL = rep.GetCloneURL()
-
-	const maxTags = 100
-
-	repoTags, _, err := client.Repositories.ListTags(ctx, owner, name, &github.ListOptions{PerPage: maxTags})
-	if err != nil {
-		return nil, nil, err
-	}
+	var (
+		repo *k6registry.Repository
+		tags []string
+		err  error
+	)
 
-	tags := make([]string, 0, len(repoTags))
+	if offline {
+		repo, tags, err = loadOfflineRepository(ctx, query)
+	} else {
+		var provider repositoryProvider
 
-	for _, tag := range repoTags {
-		tags = append(tags, tag.GetName())
-	}
+		provider, err = findProvider(ctx, module)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	return repo, tags, nil
-}
+		repo, tags, err = provider.load(ctx, query)
+		if err == nil && query.unchanged(repo) && tags == nil {
+			tags, err = unchangedTags(ctx, provider, query, repo)
+		}
 
-func loadGitLab(ctx context.Context, module string) (*k6registry.Repository, []string, error) {
-	slog.Debug("Loading GitLab repository", "module", module) //nolint:gosec // debug log
+		// The k6 module is added to every registry, it should not require a GitHub token.
+		if err != nil && isK6Module(module) && errors.Is(err, errMissingAuthToken) &&
+			!explicitReleases {
+			slog.Debug("Loading k6 repository using git", "error", err)
 
-	client, err := gitlab.NewClient("")
-	if err != nil {
-		return nil, nil, err
+			repo, tags, err = loadK6Git(ctx)
+		}
 	}
 
-	pid := strings.TrimPrefix(module, glModulePrefix)
-
-	lic := true
-
-	proj, _, err := client.Projects.GetProject(pid, &gitlab.GetProjectOptions{License: &lic}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	repo := new(k6registry.Repository)
-
-	repo.Owner = proj.Namespace.FullPath
-	repo.Name = proj.Name
-	repo.Description = proj.Description
-	repo.Stars = int(proj.StarCount)
-	repo.Archived = proj.Archived
-	repo.URL = proj.WebURL
-	repo.Homepage = proj.WebURL
-	repo.Topics = proj.Topics
-	repo.Public = len(proj.Visibility) == 0 || proj.Visibility == gitlab.PublicVisibility
-
-	repo.CloneURL = proj.HTTPURLToRepo
-
-	if proj.LastActivityAt != nil {
-		repo.Timestamp = float64(proj.LastActivityAt.Unix())
+	// Some unused metadata in the k6 repository changes too often
+	if isK6Module(module) {
+		repo.Stars = 0
+		repo.Timestamp = 0
+		repo.CloneURL = ""
 	}
 
-	if proj.License != nil {
-		for key := range validLicenses {
-			if strings.EqualFold(key, proj.License.Key) {
-				repo.License = key
-			}
+	if !offline {
+		if err := saveRepository(ctx, query, repo, tags); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	const maxTags = 50
+	return repo, tags, nil
+}
 
-	rels, _, err := client.Releases.ListReleases(pid,
-		&gitlab.ListReleasesOptions{
-			ListOptions: gitlab.ListOptions{PerPage: maxTags},
-		})
+// unchangedTags returns the tags of an unchanged repository from the repository cache.
+// If the cache entry is missing or belongs to another repository state, the tags are listed.
+func unchangedTags(
+	ctx context.Context,
+	provider repositoryProvider,
+	query repositoryQuery,
+	repo *k6registry.Repository,
+) ([]string, error) {
+	cached, tags, found, err := loadCachedRepository(ctx, query)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	tags := make([]string, 0, len(rels))
+	if found && cached.Timestamp == repo.Timestamp {
+		slog.Debug("Tags from cache", "module", query.module) //nolint:gosec // debug log
 
-	for _, rel := range rels {
-		tags = append(tags, rel.TagName)
+		return tags, nil
 	}
 
-	return repo, tags, nil
+	query.timestamp = 0
+
+	_, tags, err = provider.load(ctx, query)
+
+	return tags, err
 }
 
 func loadGit(ctx context.Context, module string, cloneURL string) ([]string, error) {
@@ -389,7 +486,11 @@ func loadGit(ctx context.Context, module string, cloneURL string) ([]string, err
 
 	dir := filepath.Join(base, module)
 
-	if err := openOrCloneBareRepo(ctx, dir, cloneURL); err != nil {
+	if contextOffline(ctx) {
+		if _, err := os.Stat(dir); err != nil { //nolint:gosec,forbidigo // modules cache dir
+			return nil, fmt.Errorf("%w: %s: %w", errOffline, module, err)
+		}
+	} else if err := openOrCloneBareRepo(ctx, dir, cloneURL); err != nil {
 		return nil, err
 	}
 
@@ -409,12 +510,43 @@ func loadGit(ctx context.Context, module string, cloneURL string) ([]string, err
 	return versions, nil
 }
 
+// loadK6Git returns the k6 repository metadata and the tags of the k6 repository listed using git,
+// for use without the GitHub API.
+func loadK6Git(ctx context.Context) (*k6registry.Repository, []string, error) {
+	tags, err := listRemoteTags(ctx, k6RepoURL+".git")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return k6Repository(), tags, nil
+}
+
+// k6Repository returns the well-known repository metadata of the k6 module,
+// matching the metadata returned by the GitHub API.
+func k6Repository() *k6registry.Repository {
+	return &k6registry.Repository{
+		Name:        "k6",
+		Owner:       "grafana",
+		Description: k6Description,
+		URL:         k6RepoURL,
+		Homepage:    k6Homepage,
+		License:     k6License,
+		Public:      true,
+		Topics: []string{
+			"es6", "go", "golang", "hacktoberfest", "javascript",
+			"k6", "load-generator", "load-testing", "performance",
+		},
+	}
+}
+
 const (
-	ghModulePrefix = "github.com/"
-	glModulePrefix = "gitlab.com/"
-	k6Module       = "go.k6.io/k6"
-	k6ImportPath   = "k6"
-	k6Description  = "A modern load testing tool, using Go and JavaScript"
+	k6RepoURL  = "https://github.com/grafana/k6"
+	k6Homepage = "https://grafana.com/oss/k6/"
+	k6License  = "AGPL-3.0"
 )
 
-var errUnsupportedModule = errors.New("unsupported module")
+const (
+	k6Module      = "go.k6.io/k6"
+	k6ImportPath  = "k6"
+	k6Description = "A modern load testing tool, using Go and JavaScript"
+)
@@ -1,7 +1,14 @@
 package cmd //nolint:testpackage
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/grafana/k6registry"
 )
 
 func Test_isK6Module(t *testing.T) {
@@ -37,3 +44,349 @@ func Test_isK6Module(t *testing.T) {
 		})
 	}
 }
+
+func newTestProvider() *fakeProvider {
+	return &fakeProvider{
+		prefix: "example.com/",
+		repos: map[string]*k6registry.Repository{
+			"example.com/xk6-foo": {Name: "xk6-foo", Owner: "example", URL: "https://example.com/xk6-foo"},
+			"example.com/xk6-bar": {Name: "xk6-bar", Owner: "example", URL: "https://example.com/xk6-bar"},
+			"go.k6.io/k6": {
+				Name: "k6", Owner: "grafana", URL: "https://github.com/grafana/k6",
+				Stars: 42, Timestamp: 1726000000, CloneURL: "https://github.com/grafana/k6.git",
+			},
+		},
+		tags: map[string][]string{
+			"example.com/xk6-foo": {"v0.1.0", "v0.3.0", "not-a-version", "v0.2.0"},
+			"example.com/xk6-bar": {"v1.0.0", "v1.1.0", "v1.2.0-rc.1", "v1.1.0-rc.1"},
+			"go.k6.io/k6":         {"v1.0.0", "v0.59.0"},
+		},
+		releases: map[string][]string{
+			"example.com/xk6-foo": {"v0.3.0"},
+			"example.com/xk6-bar": {"v1.1.0"},
+			"go.k6.io/k6":         {"v1.0.0"},
+		},
+	}
+}
+
+func newTestLoadContext(t *testing.T, providers ...repositoryProvider) context.Context {
+	t.Helper()
+
+	ctx := context.WithValue(context.Background(), cacheDirKey{}, t.TempDir())
+
+	return withProviders(ctx, providers)
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	k6 := &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags}
+	ctx := newTestLoadContext(t, provider, k6)
+
+	src := `
+- module: example.com/xk6-foo
+  imports: [k6/x/foo]
+- module: example.com/xk6-bar
+  outputs: [bar]
+  tier: official
+  constraints: ">=v1.1.0"
+`
+
+	registry, err := load(ctx, strings.NewReader(src), loadOptions{parallel: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modules := make([]string, 0, len(registry))
+	for _, ext := range registry {
+		modules = append(modules, ext.Module)
+	}
+
+	if want := []string{"example.com/xk6-foo", "example.com/xk6-bar", k6Module}; !slices.Equal(modules, want) {
+		t.Fatalf("got modules %v, want %v", modules, want)
+	}
+
+	foo := registry[0]
+
+	if foo.Tier != k6registry.TierCommunity {
+		t.Errorf("got tier %q, want %q", foo.Tier, k6registry.TierCommunity)
+	}
+
+	if want := []string{"v0.3.0", "v0.2.0", "v0.1.0"}; !slices.Equal(foo.Versions, want) {
+		t.Errorf("got versions %v, want %v", foo.Versions, want)
+	}
+
+	if foo.Repo == nil || foo.Repo.Name != "xk6-foo" {
+		t.Errorf("got repo %+v, want xk6-foo", foo.Repo)
+	}
+
+	if want := []string{"v1.1.0"}; !slices.Equal(registry[1].Versions, want) {
+		t.Errorf("got versions %v, want %v", registry[1].Versions, want)
+	}
+
+	// the frequently changing metadata of the k6 repository is dropped
+	want := &k6registry.Repository{Name: "k6", Owner: "grafana", URL: "https://github.com/grafana/k6"}
+
+	if k6 := registry[2].Repo; !reflect.DeepEqual(k6, want) {
+		t.Errorf("got k6 repo %+v, want %+v", k6, want)
+	}
+}
+
+func TestLoad_UnsupportedModule(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTestLoadContext(t, newTestProvider())
+
+	_, err := load(ctx, strings.NewReader("- module: example.org/xk6-foo\n"), loadOptions{})
+	if !errors.Is(err, errUnsupportedModule) {
+		t.Fatalf("got error %v, want %v", err, errUnsupportedModule)
+	}
+}
+
+func TestLoad_VersionSource(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	src := `
+- module: example.com/xk6-foo
+  version_source: tags
+- module: example.com/xk6-bar
+`
+
+	registry, err := load(ctx, strings.NewReader(src), loadOptions{versionSource: k6registry.VersionSourceReleases})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.3.0", "v0.2.0", "v0.1.0"}; !slices.Equal(registry[0].Versions, want) {
+		t.Errorf("got versions %v, want tags %v", registry[0].Versions, want)
+	}
+
+	if want := []string{"v1.1.0"}; !slices.Equal(registry[1].Versions, want) {
+		t.Errorf("got versions %v, want releases %v", registry[1].Versions, want)
+	}
+}
+
+func TestLoadRepository_CloneURLReleases(t *testing.T) {
+	requireGit(t)
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	ctx := newTestLoadContext(t)
+	opts := loadOptions{versionSource: k6registry.VersionSourceReleases}
+
+	// the global default falls back to tags
+	ext := &k6registry.Extension{Module: "example.com/xk6-foo", Repo: &k6registry.Repository{CloneURL: remote}}
+
+	_, tags, err := loadRepository(ctx, ext, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) == 0 {
+		t.Error("got no tags, want the tags of the clone URL")
+	}
+
+	// the releases set by the extension are required
+	ext.VersionSource = k6registry.VersionSourceReleases
+
+	if _, _, err := loadRepository(ctx, ext, opts); !errors.Is(err, errUnsupportedVersionSource) {
+		t.Fatalf("got error %v, want %v", err, errUnsupportedVersionSource)
+	}
+}
+
+func TestLoad_InvalidVersionSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTestLoadContext(t, newTestProvider())
+
+	_, err := load(ctx, strings.NewReader("- module: example.com/xk6-foo\n"), loadOptions{versionSource: "branches"})
+	if !errors.Is(err, errUnsupportedVersionSource) {
+		t.Fatalf("got error %v, want %v", err, errUnsupportedVersionSource)
+	}
+
+	_, err = load(ctx, strings.NewReader("- module: example.com/xk6-foo\n  version_source: branches\n"), loadOptions{})
+	if !errors.Is(err, errInvalidRegistry) {
+		t.Fatalf("got error %v, want %v", err, errInvalidRegistry)
+	}
+}
+
+func TestLoad_Prerelease(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+
+	for _, module := range []string{"example.com/xk6-bar-latest", "example.com/xk6-bar-all"} {
+		repo := *provider.repos["example.com/xk6-bar"]
+
+		provider.repos[module] = &repo
+		provider.tags[module] = provider.tags["example.com/xk6-bar"]
+	}
+
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	src := `
+- module: example.com/xk6-bar
+- module: example.com/xk6-bar-latest
+  prerelease: latest-only
+- module: example.com/xk6-bar-all
+  prerelease: include
+`
+
+	registry, err := load(ctx, strings.NewReader(src), loadOptions{prerelease: k6registry.PrereleaseExclude})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"v1.1.0", "v1.0.0"},
+		{"v1.2.0-rc.1", "v1.1.0", "v1.0.0"},
+		{"v1.2.0-rc.1", "v1.1.0", "v1.1.0-rc.1", "v1.0.0"},
+	}
+
+	for idx, versions := range want {
+		if !slices.Equal(registry[idx].Versions, versions) {
+			t.Errorf("extension %d: got versions %v, want %v", idx, registry[idx].Versions, versions)
+		}
+	}
+
+	_, err = load(ctx, strings.NewReader(src), loadOptions{prerelease: "none"})
+	if !errors.Is(err, errInvalidPrerelease) {
+		t.Fatalf("got error %v, want %v", err, errInvalidPrerelease)
+	}
+}
+
+func TestLoad_Previous(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	provider.repos["example.com/xk6-foo"].Timestamp = 1725962400
+	provider.repos["example.com/xk6-bar"].Timestamp = 1725962400
+
+	k6 := &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags}
+	ctx := newTestLoadContext(t, provider, k6)
+
+	src := "- module: example.com/xk6-foo\n  constraints: \">=v0.3.0\"\n- module: example.com/xk6-bar\n"
+
+	first, err := load(ctx, strings.NewReader(src), loadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.3.0"}; !slices.Equal(first[0].Versions, want) {
+		t.Fatalf("got versions %v, want %v", first[0].Versions, want)
+	}
+
+	previous := extensionsByModule(first)
+
+	previous["example.com/xk6-bar"].Repo.Timestamp = 1725960000
+
+	// listed only if the repository is modified
+	provider.tags["example.com/xk6-foo"] = append(provider.tags["example.com/xk6-foo"], "v0.4.0")
+	provider.tags["example.com/xk6-bar"] = append(provider.tags["example.com/xk6-bar"], "v1.3.0")
+
+	// the versions of the unchanged repository are selected again from the cached tags
+	src = "- module: example.com/xk6-foo\n- module: example.com/xk6-bar\n  prerelease: exclude\n"
+
+	registry, err := load(ctx, strings.NewReader(src), loadOptions{previous: previous})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.3.0", "v0.2.0", "v0.1.0"}; !slices.Equal(registry[0].Versions, want) {
+		t.Errorf("unchanged repository: got versions %v, want %v", registry[0].Versions, want)
+	}
+
+	if want := []string{"v1.3.0", "v1.1.0", "v1.0.0"}; !slices.Equal(registry[1].Versions, want) {
+		t.Errorf("modified repository: got versions %v, want %v", registry[1].Versions, want)
+	}
+
+	// without cached tags, the tags of the unchanged repository are listed
+	ctx = newTestLoadContext(t, provider, k6)
+
+	registry, err = load(ctx, strings.NewReader(src), loadOptions{previous: previous})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.4.0", "v0.3.0", "v0.2.0", "v0.1.0"}; !slices.Equal(registry[0].Versions, want) {
+		t.Errorf("uncached repository: got versions %v, want %v", registry[0].Versions, want)
+	}
+}
+
+func TestLoad_PreviousCompliance(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	provider.repos["example.com/xk6-foo"].Timestamp = 1725962400
+
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	previous := map[string]*k6registry.Extension{
+		"example.com/xk6-foo": {
+			Module:   "example.com/xk6-foo",
+			Repo:     &k6registry.Repository{Name: "xk6-foo", Timestamp: 1725962400},
+			Versions: []string{"v0.3.0"},
+			Compliance: k6registry.ExtensionCompliance{
+				"v0.3.0": {Checks: []string{"readme", "versions"}, Issues: []string{"readme"}},
+			},
+		},
+	}
+
+	src := "- module: example.com/xk6-foo\n  constraints: \">=v0.3.0\"\n"
+
+	// the previous compliance results are reused instead of running the linter
+	_, err := load(ctx, strings.NewReader(src), loadOptions{
+		previous:   previous,
+		lint:       true,
+		lintChecks: []string{"versions", "readme"},
+	})
+	if !errors.Is(err, errCompliance) {
+		t.Fatalf("got error %v, want %v", err, errCompliance)
+	}
+
+	// the previous compliance results of other checks are not reused
+	_, err = load(ctx, strings.NewReader(src), loadOptions{previous: previous, lint: true, lintChecks: []string{"readme"}})
+	if err == nil || errors.Is(err, errCompliance) {
+		t.Fatalf("got error %v, want the linter to run", err)
+	}
+}
+
+func TestLoad_LintSelectedVersions(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	provider.repos["example.com/xk6-foo"].Timestamp = 1725962400
+
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	// the previous compliance results stand in for the linter, which is not available in tests
+	previous := map[string]*k6registry.Extension{
+		"example.com/xk6-foo": {
+			Module:   "example.com/xk6-foo",
+			Repo:     &k6registry.Repository{Name: "xk6-foo", Timestamp: 1725962400},
+			Versions: []string{"v0.3.0", "v0.2.0", "v0.1.0"},
+			Compliance: k6registry.ExtensionCompliance{
+				"v0.3.0": {}, "v0.2.0": {}, "v0.1.0": {},
+			},
+		},
+	}
+
+	src := "- module: example.com/xk6-foo\n  constraints: \"<v0.3.0\"\n"
+
+	registry, err := load(ctx, strings.NewReader(src), loadOptions{previous: previous, lint: true, maxVersionsPerMajor: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"v0.2.0"}; !slices.Equal(registry[0].Versions, want) {
+		t.Fatalf("got versions %v, want %v", registry[0].Versions, want)
+	}
+
+	if len(registry[0].Compliance) != 1 {
+		t.Fatalf("got compliance of versions %v, want only the selected versions", registry[0].Compliance)
+	}
+}
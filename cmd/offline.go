@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/k6registry"
+)
+
+var errOffline = errors.New("no cached data available in offline mode")
+
+type offlineKey struct{}
+
+// withOffline returns a copy of ctx in which the registry is generated purely from caches.
+func withOffline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, offlineKey{}, true)
+}
+
+// contextOffline reports whether ctx is in offline mode.
+func contextOffline(ctx context.Context) bool {
+	offline, _ := ctx.Value(offlineKey{}).(bool)
+
+	return offline
+}
+
+// offlineTransport fails every request, so only the responses cached by the
+// GitHub CLI HTTP client are available.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: %s %s", errOffline, req.Method, req.URL.Redacted())
+}
+
+// cachedRepository is the repository cache entry of a module.
+type cachedRepository struct {
+	Repo *k6registry.Repository `json:"repo"`
+	Tags []string               `json:"tags"`
+}
+
+// repositoryCacheFile returns the name of the repository cache file of the query.
+// The tags depend on the version source and the skipped prereleases, so they are part of the name.
+func repositoryCacheFile(ctx context.Context, query repositoryQuery) (string, error) {
+	base, err := reposDir(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name := string(query.versionSource)
+	if len(name) == 0 {
+		name = "default"
+	}
+
+	if query.skipPrereleases {
+		name += "-stable"
+	}
+
+	return filepath.Join(base, query.module, name) + ".json", nil
+}
+
+// saveRepository stores the repository metadata and the tags of the query in the repository cache.
+func saveRepository(ctx context.Context, query repositoryQuery, repo *k6registry.Repository, tags []string) error {
+	filename, err := repositoryCacheFile(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), permDir); err != nil { //nolint:gosec,forbidigo // cache dir
+		return err
+	}
+
+	data, err := json.Marshal(&cachedRepository{Repo: repo, Tags: tags})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, permFile) //nolint:gosec,forbidigo // cache dir
+}
+
+// loadCachedRepository returns the repository metadata and the tags of the query from the repository cache.
+func loadCachedRepository(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, bool, error) {
+	filename, err := repositoryCacheFile(ctx, query)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	data, err := os.ReadFile(filepath.Clean(filename)) //nolint:gosec,forbidigo // cache dir
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, false, nil
+		}
+
+		return nil, nil, false, err
+	}
+
+	var entry cachedRepository
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil, false, err
+	}
+
+	if entry.Repo == nil {
+		return nil, nil, false, nil
+	}
+
+	return entry.Repo, entry.Tags, true, nil
+}
+
+// loadOfflineRepository returns the repository metadata and the tags of the query without network access.
+//
+// The repository cache written by the previous online runs is used first. GitHub repositories
+// missing from it are loaded using the responses cached by the GitHub CLI HTTP client.
+func loadOfflineRepository(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	repo, tags, found, err := loadCachedRepository(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if found {
+		slog.Debug("Repository from cache", "module", query.module) //nolint:gosec // debug log
+
+		return repo, tags, nil
+	}
+
+	provider, err := findProvider(ctx, query.module)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Only the GitHub client from the context is backed by the offline HTTP cache,
+	// which is not available without a token.
+	if gh, ok := provider.(*githubProvider); ok && gh.client == nil {
+		repo, tags, err := gh.load(ctx, query)
+		if err == nil {
+			return repo, tags, nil
+		}
+
+		if !errors.Is(err, errOffline) && !errors.Is(err, errMissingAuthToken) {
+			return nil, nil, err
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", errOffline, query.module)
+}
@@ -0,0 +1,98 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Offline(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	src := `
+- module: example.com/xk6-foo
+- module: example.com/xk6-bar
+  version_source: releases
+`
+
+	online, err := load(ctx, strings.NewReader(src), loadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the providers have no data, everything must come from the cache
+	ctx = withOffline(withProviders(ctx, []repositoryProvider{
+		&fakeProvider{prefix: k6Module},
+		&fakeProvider{prefix: "example.com/"},
+	}))
+
+	offline, err := load(ctx, strings.NewReader(src), loadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(online, offline) {
+		t.Fatalf("got offline registry %+v, want %+v", offline, online)
+	}
+
+	// all extensions without cached data are reported
+	src = `
+- module: example.com/xk6-foo
+  version_source: tags
+- module: example.com/xk6-bar
+  version_source: releases
+- module: example.com/xk6-baz
+`
+
+	_, err = load(ctx, strings.NewReader(src), loadOptions{})
+	if !errors.Is(err, errOffline) {
+		t.Fatalf("got error %v, want %v", err, errOffline)
+	}
+
+	for _, module := range []string{"example.com/xk6-foo", "example.com/xk6-baz"} {
+		if !strings.Contains(err.Error(), module) {
+			t.Errorf("missing module %s not reported: %v", module, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "example.com/xk6-bar") {
+		t.Errorf("cached module reported: %v", err)
+	}
+}
+
+func TestLoadGit_Offline(t *testing.T) {
+	t.Parallel()
+
+	ctx := withOffline(newTestLoadContext(t))
+
+	_, err := loadGit(ctx, "example.com/xk6-foo", "https://example.com/xk6-foo.git")
+	if !errors.Is(err, errOffline) {
+		t.Fatalf("got error %v, want %v", err, errOffline)
+	}
+}
+
+func TestOfflineTransport(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{Transport: offlineTransport{}}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.github.com/repos/grafana/k6", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+
+	if !errors.Is(err, errOffline) {
+		t.Fatalf("got error %v, want %v", err, errOffline)
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/k6registry"
+)
+
+// namedOutput is a configured output file with its compiled query and encoder.
+type namedOutput struct {
+	path    string
+	query   *outputQuery
+	encoder registryEncoder
+	compact bool
+}
+
+// outputs returns the configured outputs.
+// The filters and formats are validated before the generation, so a configuration error
+// does not waste the expensive metadata collection.
+func (c *config) outputs() ([]*namedOutput, error) {
+	outputs := make([]*namedOutput, 0, len(c.Outputs))
+	paths := make(map[string]bool, len(c.Outputs))
+
+	for _, out := range c.Outputs {
+		if len(out.Path) == 0 {
+			return nil, fmt.Errorf("%w: output requires path", errInvalidConfig)
+		}
+
+		if paths[out.Path] {
+			return nil, fmt.Errorf("%w: duplicate output path %s", errInvalidConfig, out.Path)
+		}
+
+		paths[out.Path] = true
+
+		format := out.Format
+		if len(format) == 0 {
+			format = defaultFormat
+		}
+
+		encoder, err := findEncoder(format)
+		if err != nil {
+			return nil, fmt.Errorf("%w: output %s: %w", errInvalidConfig, out.Path, err)
+		}
+
+		query, err := newOutputQuery(out.Filter, out.Select)
+		if err != nil {
+			return nil, fmt.Errorf("%w: output %s: %w", errInvalidConfig, out.Path, err)
+		}
+
+		outputs = append(outputs, &namedOutput{path: out.Path, query: query, encoder: encoder, compact: out.Compact})
+	}
+
+	return outputs, nil
+}
+
+// encode returns the query result of the registry in the output format.
+func (o *namedOutput) encode(registry k6registry.Registry) ([]byte, error) {
+	registry, err := o.query.apply(registry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", o.path, err)
+	}
+
+	var buff bytes.Buffer
+
+	if err := o.encoder(registry, &buff, o.compact); err != nil {
+		return nil, fmt.Errorf("%s: %w", o.path, err)
+	}
+
+	return buff.Bytes(), nil
+}
+
+// writeOutputs writes the registry to each output.
+// All outputs are encoded before any file is replaced, so an encoding failure leaves every file unchanged.
+// The files are replaced only if their content has changed, and signed if signer is not nil.
+func writeOutputs(registry k6registry.Registry, outputs []*namedOutput, signer ed25519.PrivateKey) error {
+	contents := make([][]byte, 0, len(outputs))
+
+	for _, out := range outputs {
+		data, err := out.encode(registry)
+		if err != nil {
+			return err
+		}
+
+		contents = append(contents, data)
+	}
+
+	for idx, out := range outputs {
+		if _, err := writeSigned(out.path, contents[idx], signer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceFile replaces the content of filename with data, unless it already has the same content.
+// The data is written to a temporary file in the same directory, which is then renamed to filename,
+// so readers never see a partially written file. It reports whether the file has been changed.
+func replaceFile(filename string, data []byte) (bool, error) {
+	current, err := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+	if err == nil && bytes.Equal(current, data) {
+		return false, nil
+	}
+
+	tmp, err := writeTemp(filename, data)
+	if err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(tmp, filename); err != nil { //nolint:forbidigo // CLI tool
+		_ = os.Remove(tmp) //nolint:forbidigo // CLI tool
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// writeTemp writes data to a new temporary file in the directory of filename and returns its name.
+func writeTemp(filename string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*") //nolint:forbidigo // CLI tool
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(permFile)
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name()) //nolint:forbidigo // CLI tool
+
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
@@ -0,0 +1,230 @@
+package cmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func TestConfigOutputs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeFileT(t, dir, "config.yaml", `
+outputs:
+  - path: official.json
+    filter: tier == "official"
+    compact: true
+  - path: `+filepath.Join(dir, "catalog.csv")+`
+    format: csv
+  - path: `+filepath.Join(dir, "versions.json")+`
+    select: [versions]
+`)
+
+	cfg, err := readConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := cfg.outputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeOutputs(testFilterRegistry(), outputs, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "official.json")) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(string(data), "\n") != 1 {
+		t.Fatalf("expected compact output, got %s", data)
+	}
+
+	var official k6registry.Registry
+
+	if err := json.Unmarshal(data, &official); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(official) != 2 {
+		t.Fatalf("got %d official extensions, want 2", len(official))
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "catalog.csv")) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(data), "module,tier,license,stars,latest_version\n") {
+		t.Fatalf("unexpected CSV output %s", data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "versions.json")) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), `"repo"`) || !strings.Contains(string(data), `"versions"`) {
+		t.Fatalf("unexpected selected output %s", data)
+	}
+}
+
+func TestConfigOutputs_Invalid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"missing path":   "outputs:\n  - format: json\n",
+		"duplicate path": "outputs:\n  - path: a.json\n  - path: a.json\n",
+		"invalid format": "outputs:\n  - path: a.json\n    format: xml\n",
+		"invalid filter": "outputs:\n  - path: a.json\n    filter: tier ==\n",
+		"unknown select": "outputs:\n  - path: a.json\n    select: [foo]\n",
+	}
+
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			writeFileT(t, dir, "config.yaml", content)
+
+			cfg, err := readConfig(filepath.Join(dir, "config.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := cfg.outputs(); !errors.Is(err, errInvalidConfig) {
+				t.Fatalf("got error %v, want %v", err, errInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestRun_Output(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	ctx := newTestLoadContext(t, provider, &fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags})
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "registry.json")
+
+	writeFileT(t, dir, "registry.json", "previous\n")
+	writeFileT(t, dir, "invalid.yaml", "- module: example.org/xk6-foo\n")
+	writeFileT(t, dir, "registry.yaml", "- module: example.com/xk6-foo\n")
+
+	// a failed generation leaves the previous output in place
+	err := run(ctx, []string{filepath.Join(dir, "invalid.yaml")}, &options{out: out, format: defaultFormat})
+	if !errors.Is(err, errUnsupportedModule) {
+		t.Fatalf("got error %v, want %v", err, errUnsupportedModule)
+	}
+
+	data, err := os.ReadFile(out) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "previous\n" {
+		t.Fatalf("output changed after failed generation: %s", data)
+	}
+
+	if err := run(ctx, []string{filepath.Join(dir, "registry.yaml")}, &options{out: out, format: defaultFormat}); err != nil {
+		t.Fatal(err)
+	}
+
+	var registry k6registry.Registry
+
+	data, err = os.ReadFile(out) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(data, &registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(registry) != 2 || registry[0].Module != "example.com/xk6-foo" {
+		t.Fatalf("unexpected output %s", data)
+	}
+
+	entries, err := os.ReadDir(dir) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("got %d files, want no temporary files left", len(entries))
+	}
+}
+
+func TestWriteOutputs_Failure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	outputs := []*namedOutput{
+		{path: filepath.Join(dir, "registry.json"), query: new(outputQuery), encoder: encodeJSON},
+		{path: filepath.Join(dir, "catalog.json"), query: new(outputQuery), encoder: encodeCatalog},
+	}
+
+	registry := testFilterRegistry()
+
+	registry[2].Imports = []string{"k6/x/sql"}
+
+	if err := writeOutputs(registry, outputs, nil); !errors.Is(err, errCatalogCollision) {
+		t.Fatalf("got error %v, want %v", err, errCatalogCollision)
+	}
+
+	entries, err := os.ReadDir(dir) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("got %d files, want none after failed encoding", len(entries))
+	}
+}
+
+func TestEmit_Failure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeFileT(t, dir, "registry.json", "previous\n")
+
+	outputs := []*namedOutput{
+		{path: filepath.Join(dir, "registry.json"), query: new(outputQuery), encoder: encodeJSON},
+	}
+
+	registry := testFilterRegistry()
+
+	registry[2].Imports = []string{"k6/x/sql"}
+
+	var buff bytes.Buffer
+
+	// the main output fails to encode, so the configured outputs are not replaced
+	err := emit(registry, &buff, outputs, new(outputQuery), encodeCatalog, &options{format: "catalog"})
+	if !errors.Is(err, errCatalogCollision) {
+		t.Fatalf("got error %v, want %v", err, errCatalogCollision)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "registry.json")) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "previous\n" || buff.Len() != 0 {
+		t.Fatalf("output changed after failed encoding: %s", data)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+)
+
+// runParallel calls fn for each index in [0, count) using at most limit concurrent goroutines.
+// A limit less than 1 means sequential processing.
+//
+// The first error returned by fn cancels the context passed to the remaining calls,
+// stops scheduling new calls and is returned after all started calls have finished.
+// Results should be stored by index, so the caller can keep the original order.
+func runParallel(ctx context.Context, count int, limit int, fn func(ctx context.Context, idx int) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+
+	for idx := range count {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(ctx, idx); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
@@ -0,0 +1,101 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errWorker = errors.New("worker failed")
+
+func TestRunParallel(t *testing.T) {
+	t.Parallel()
+
+	const (
+		count = 20
+		limit = 3
+	)
+
+	var running, peak atomic.Int32
+
+	results := make([]int, count)
+
+	err := runParallel(context.Background(), count, limit, func(_ context.Context, idx int) error {
+		cur := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		results[idx] = idx * idx
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := peak.Load(); got > limit {
+		t.Fatalf("got %d concurrent calls, want at most %d", got, limit)
+	}
+
+	for idx, got := range results {
+		if got != idx*idx {
+			t.Fatalf("results[%d] = %d, want %d", idx, got, idx*idx)
+		}
+	}
+}
+
+func TestRunParallel_Sequential(t *testing.T) {
+	t.Parallel()
+
+	var order []int
+
+	err := runParallel(context.Background(), 5, 0, func(_ context.Context, idx int) error {
+		order = append(order, idx)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, got := range order {
+		if got != idx {
+			t.Fatalf("got call order %v, want ascending", order)
+		}
+	}
+}
+
+func TestRunParallel_Error(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	err := runParallel(context.Background(), 100, 2, func(ctx context.Context, idx int) error {
+		calls.Add(1)
+
+		if idx == 0 {
+			return errWorker
+		}
+
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+	if !errors.Is(err, errWorker) {
+		t.Fatalf("got error %v, want %v", err, errWorker)
+	}
+
+	if got := calls.Load(); got == 100 {
+		t.Fatal("expected remaining calls to be skipped after the first error")
+	}
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+var (
+	errUnsupportedModule = errors.New("unsupported module")
+	errRequestFailed     = errors.New("request failed")
+	errNotFound          = errors.New("not found")
+
+	errUnsupportedVersionSource = errors.New("unsupported version source")
+)
+
+// httpTimeout is the timeout of the HTTP clients created by providers.
+const httpTimeout = time.Minute
+
+// repositoryQuery contains the parameters of loading repository metadata.
+type repositoryQuery struct {
+	// The extension's go module path.
+	module string
+
+	// Source of the versions, the provider specific default if empty.
+	versionSource k6registry.VersionSource
+
+	// The version source is the global default, not set by the extension.
+	// Providers without releases list the tags instead of failing.
+	defaultSource bool
+
+	// Skip the releases marked as prerelease. Draft releases are always skipped.
+	skipPrereleases bool
+
+	// Repository timestamp of the previous generation, zero if unknown.
+	// The versions are not listed if the repository has not been modified since.
+	timestamp float64
+}
+
+// unchanged reports whether repo has not been modified since the previous generation.
+func (q repositoryQuery) unchanged(repo *k6registry.Repository) bool {
+	return q.timestamp > 0 && repo.Timestamp == q.timestamp
+}
+
+// repositoryProvider queries repository metadata from a repository manager API.
+type repositoryProvider interface {
+	// match reports whether the repository of module is handled by the provider.
+	match(module string) bool
+
+	// load returns the repository metadata and the tag names of the versions of the queried module.
+	// The tag names are not listed if the repository is unchanged according to the query.
+	load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error)
+}
+
+// defaultProviders returns the built-in repository providers.
+func defaultProviders() []repositoryProvider {
+	return []repositoryProvider{
+		newGitHubProvider(k6Module, nil),
+		newGitHubProvider(ghModulePrefix, nil),
+		newGitLabProvider(glModulePrefix, "", ""),
+		newGiteaProvider(cbModulePrefix, cbBaseURL, ""),
+		newBitbucketProvider(bbModulePrefix, bbAPIURL, ""),
+	}
+}
+
+// lazyProvider creates the underlying provider on first use, so the credentials
+// of a repository manager are only required if it is actually used.
+type lazyProvider struct {
+	prefix string
+	create func() (repositoryProvider, error)
+}
+
+// newLazyProvider returns a provider for modules starting with prefix.
+// The underlying provider is created by create once, on first load.
+func newLazyProvider(prefix string, create func() (repositoryProvider, error)) *lazyProvider {
+	return &lazyProvider{prefix: prefix, create: sync.OnceValues(create)}
+}
+
+func (p *lazyProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+func (p *lazyProvider) load(ctx context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	provider, err := p.create()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return provider.load(ctx, query)
+}
+
+type providersKey struct{}
+
+// withProviders returns a copy of ctx that uses providers to load repository metadata.
+func withProviders(ctx context.Context, providers []repositoryProvider) context.Context {
+	return context.WithValue(ctx, providersKey{}, providers)
+}
+
+// contextProviders returns the repository providers from context.
+func contextProviders(ctx context.Context) ([]repositoryProvider, error) {
+	value := ctx.Value(providersKey{})
+	if value != nil {
+		if providers, ok := value.([]repositoryProvider); ok {
+			return providers, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: missing repository providers", errInvalidContext)
+}
+
+// findProvider returns the first provider from context that matches module.
+func findProvider(ctx context.Context, module string) (repositoryProvider, error) {
+	providers, err := contextProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, provider := range providers {
+		if provider.match(module) {
+			return provider, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnsupportedModule, module)
+}
+
+// moduleToOwnerAndName returns the repository owner and name from the first two
+// elements of the module path after prefix.
+func moduleToOwnerAndName(prefix string, module string) (string, string) {
+	if isK6Module(module) {
+		return "grafana", "k6"
+	}
+
+	const maxParts = 3
+
+	parts := strings.SplitN(strings.Trim(strings.TrimPrefix(module, prefix), "/"), "/", maxParts)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// authTransport sets the Authorization header of the requests to the given scheme and token.
+type authTransport struct {
+	scheme string
+	token  string
+	base   http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", t.scheme+" "+t.token)
+
+	return t.base.RoundTrip(req)
+}
+
+// newProviderHTTPClient returns the HTTP client used by REST API based providers.
+// If token is not empty, requests are authenticated using the given authorization scheme.
+// Requests are sent conditionally if a previous response of the same request is available.
+func newProviderHTTPClient(scheme string, token string) *http.Client {
+	client := &http.Client{Timeout: httpTimeout, Transport: newConditionalTransport(nil)}
+
+	if len(token) > 0 {
+		client.Transport = &authTransport{scheme: scheme, token: token, base: client.Transport}
+	}
+
+	return client
+}
+
+// getJSON sends a GET request to url and decodes the JSON response body into target.
+func getJSON(ctx context.Context, client *http.Client, url string, target any) error {
+	body, err := getBody(ctx, client, url, "application/json")
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, target)
+}
+
+// getBody sends a GET request to url and returns the response body.
+// If the resource is not found, the returned error wraps errNotFound as well.
+func getBody(ctx context.Context, client *http.Client, url string, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req) //nolint:gosec // URL built from provider base URL
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: GET %s: %w", errRequestFailed, url, errNotFound)
+	default:
+		return nil, fmt.Errorf("%w: GET %s: %s", errRequestFailed, url, resp.Status)
+	}
+}
+
+// findLicense returns the valid SPDX license ID matching id case-insensitively.
+func findLicense(id string) (string, bool) {
+	for key := range validLicenses {
+		if strings.EqualFold(key, id) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,173 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+// fakeProvider serves repository metadata and tags from memory for modules with the given prefix.
+// If releases are queried, the tags of the releases map are returned.
+type fakeProvider struct {
+	prefix   string
+	repos    map[string]*k6registry.Repository
+	tags     map[string][]string
+	releases map[string][]string
+}
+
+func (p *fakeProvider) match(module string) bool {
+	return strings.HasPrefix(module, p.prefix)
+}
+
+func (p *fakeProvider) load(_ context.Context, query repositoryQuery) (*k6registry.Repository, []string, error) {
+	repo, found := p.repos[query.module]
+	if !found {
+		return nil, nil, errUnsupportedModule
+	}
+
+	clone := *repo
+
+	if query.unchanged(&clone) {
+		return &clone, nil, nil
+	}
+
+	if query.versionSource == k6registry.VersionSourceReleases {
+		return &clone, p.releases[query.module], nil
+	}
+
+	return &clone, p.tags[query.module], nil
+}
+
+func TestFindProvider(t *testing.T) {
+	t.Parallel()
+
+	first := &fakeProvider{prefix: "example.com/"}
+	second := &fakeProvider{prefix: "example.com/special/"}
+
+	ctx := withProviders(context.Background(), []repositoryProvider{first, second})
+
+	provider, err := findProvider(ctx, "example.com/special/xk6-foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if provider != first {
+		t.Fatal("expected the first matching provider to win")
+	}
+
+	_, err = findProvider(ctx, "example.org/xk6-foo")
+	if !errors.Is(err, errUnsupportedModule) {
+		t.Fatalf("got error %v, want %v", err, errUnsupportedModule)
+	}
+}
+
+func TestFindProvider_MissingProviders(t *testing.T) {
+	t.Parallel()
+
+	_, err := findProvider(context.Background(), "github.com/grafana/xk6-foo")
+	if !errors.Is(err, errInvalidContext) {
+		t.Fatalf("got error %v, want %v", err, errInvalidContext)
+	}
+}
+
+func TestDefaultProviders(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		module string
+		want   repositoryProvider
+	}{
+		{"github.com/grafana/xk6-dashboard", new(githubProvider)},
+		{"go.k6.io/k6", new(githubProvider)},
+		{"gitlab.com/szkiba/xk6-banner", new(gitlabProvider)},
+		{"codeberg.org/szkiba/xk6-codename", new(giteaProvider)},
+		{"bitbucket.org/szkiba/xk6-sqids", new(bitbucketProvider)},
+		{"example.com/xk6-foo", nil},
+	}
+
+	ctx := withProviders(context.Background(), defaultProviders())
+
+	for _, c := range cases {
+		provider, err := findProvider(ctx, c.module)
+		if c.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got provider %T", c.module, provider)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", c.module, err)
+
+			continue
+		}
+
+		if got, want := fmt.Sprintf("%T", provider), fmt.Sprintf("%T", c.want); got != want {
+			t.Errorf("%s: got provider %s, want %s", c.module, got, want)
+		}
+	}
+}
+
+func TestModuleToOwnerAndName(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		prefix, module string
+		owner, name    string
+	}{
+		{"github.com/", "github.com/grafana/xk6-sql", "grafana", "xk6-sql"},
+		{"github.com/", "github.com/grafana/xk6-sql/v2", "grafana", "xk6-sql"},
+		{"go.k6.io/k6", "go.k6.io/k6/v2", "grafana", "k6"},
+		{"git.example.com", "git.example.com/team/xk6-foo", "team", "xk6-foo"},
+		{"git.example.com/mirror/", "git.example.com/mirror/team/xk6-foo", "team", "xk6-foo"},
+		{"git.example.com/", "git.example.com/team", "team", ""},
+	}
+
+	for _, c := range cases {
+		owner, name := moduleToOwnerAndName(c.prefix, c.module)
+		if owner != c.owner || name != c.name {
+			t.Errorf("moduleToOwnerAndName(%q, %q) = %q, %q, want %q, %q",
+				c.prefix, c.module, owner, name, c.owner, c.name)
+		}
+	}
+}
+
+func TestLazyProvider(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+
+	provider := newLazyProvider("example.com/", func() (repositoryProvider, error) {
+		calls++
+
+		return newTestProvider(), nil
+	})
+
+	if !provider.match("example.com/xk6-foo") || provider.match("example.org/xk6-foo") {
+		t.Fatal("unexpected match result")
+	}
+
+	if calls != 0 {
+		t.Fatal("expected the provider to be created on first load")
+	}
+
+	for range 2 {
+		repo, _, err := provider.load(context.Background(), repositoryQuery{module: "example.com/xk6-foo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if repo.Name != "xk6-foo" {
+			t.Fatalf("got repo %+v, want xk6-foo", repo)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("got %d provider creations, want 1", calls)
+	}
+}
@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/grafana/k6registry"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultServeAddr  = "localhost:8080"
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+type serveOptions struct {
+	addr     string
+	source   bool
+	interval time.Duration
+}
+
+func serveCmd() *cobra.Command {
+	opts := new(serveOptions)
+
+	cmd := &cobra.Command{
+		Use:   "serve [flags] registry-file",
+		Short: "Serve the registry over HTTP",
+		Long: `Serve the registry over HTTP.
+
+The registry is read from a previously generated registry file. Using the --source flag, the file is a registry source and the registry is generated from it (with the default generation settings).
+
+Using the --interval flag, the registry is read (or generated) again periodically. If it fails, the previous registry is served further.
+
+Endpoints:
+
+  GET /registry.json                the whole registry
+  GET /registry.schema.json         the registry JSON schema
+  GET /extensions                   the extensions, optionally restricted by the filter and select query parameters
+  GET /extensions/{module}          the extension of the module
+  GET /versions/{module}            the versions of the module
+  GET /resolve/{kind}/{name}        the extension registering the name of the kind (imports, outputs or subcommands),
+                                    for example /resolve/imports/k6/x/sql or /resolve/outputs/dashboard
+
+The responses have an ETag header, conditional requests (If-None-Match) are answered with 304 Not Modified if the response is unchanged.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(cmd.Context(), args[0], opts)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVar(&opts.addr, "addr", defaultServeAddr, "address to listen on")
+	flags.BoolVar(&opts.source, "source", false, "the file is a registry source, the registry is generated from it")
+	flags.DurationVar(&opts.interval, "interval", 0, "read or generate the registry again periodically (0 means never)")
+
+	return cmd
+}
+
+func serve(ctx context.Context, filename string, opts *serveOptions) error {
+	readRegistryFile := func(ctx context.Context) (k6registry.Registry, error) {
+		if !opts.source {
+			return readRegistry(filename)
+		}
+
+		return generateRegistry(ctx, filename)
+	}
+
+	registry, err := readRegistryFile(ctx)
+	if err != nil {
+		return err
+	}
+
+	server := new(registryServer)
+
+	if err := server.update(registry); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.interval > 0 {
+		go server.refresh(ctx, opts.interval, readRegistryFile)
+	}
+
+	httpServer := &http.Server{
+		Addr:              opts.addr,
+		Handler:           server.handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Serving registry", "addr", opts.addr, "extensions", len(registry))
+
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// generateRegistry generates the registry from the source file with the default settings.
+func generateRegistry(ctx context.Context, filename string) (registry k6registry.Registry, result error) {
+	file, err := os.Open(filename) //nolint:forbidigo // CLI tool
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := file.Close()
+		if result == nil && err != nil {
+			result = err
+		}
+	}()
+
+	return load(withGitHubClient(ctx), file, loadOptions{})
+}
+
+// registryServer serves the registry over HTTP.
+// The served registry can be replaced while serving.
+type registryServer struct {
+	mu sync.RWMutex
+
+	registry k6registry.Registry
+	modules  map[string]*k6registry.Extension
+	catalog  catalog
+}
+
+// update replaces the served registry.
+func (s *registryServer) update(registry k6registry.Registry) error {
+	cat, err := newCatalog(registry)
+	if err != nil {
+		return err
+	}
+
+	modules := make(map[string]*k6registry.Extension, len(registry))
+
+	for idx := range registry {
+		modules[registry[idx].Module] = &registry[idx]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.registry, s.modules, s.catalog = registry, modules, cat
+
+	return nil
+}
+
+// refresh updates the served registry periodically until ctx is done.
+func (s *registryServer) refresh(
+	ctx context.Context,
+	interval time.Duration,
+	read func(ctx context.Context) (k6registry.Registry, error),
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		registry, err := read(ctx)
+		if err == nil {
+			err = s.update(registry)
+		}
+
+		if err != nil {
+			slog.Warn("Failed to refresh registry, serving the previous one", "error", err)
+
+			continue
+		}
+
+		slog.Debug("Registry refreshed", "extensions", len(registry))
+	}
+}
+
+func (s *registryServer) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /registry.json", s.handleRegistry)
+	mux.HandleFunc("GET /registry.schema.json", handleSchema)
+	mux.HandleFunc("GET /extensions", s.handleExtensions)
+	mux.HandleFunc("GET /extensions/{module...}", s.handleExtension)
+	mux.HandleFunc("GET /versions/{module...}", s.handleVersions)
+	mux.HandleFunc("GET /resolve/{kind}/{name...}", s.handleResolve)
+
+	return mux
+}
+
+func (s *registryServer) handleRegistry(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	writeJSON(w, r, s.registry)
+}
+
+func handleSchema(w http.ResponseWriter, r *http.Request) {
+	writeBody(w, r, k6registry.Schema)
+}
+
+func (s *registryServer) handleExtensions(w http.ResponseWriter, r *http.Request) {
+	var fields []string
+
+	if sel := r.URL.Query().Get("select"); len(sel) > 0 {
+		fields = strings.Split(sel, ",")
+	}
+
+	query, err := newOutputQuery(r.URL.Query().Get("filter"), fields)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	registry, err := query.apply(s.registry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	writeJSON(w, r, registry)
+}
+
+func (s *registryServer) handleExtension(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ext, found := s.modules[r.PathValue("module")]
+	if !found {
+		writeError(w, http.StatusNotFound, errModuleNotFound)
+
+		return
+	}
+
+	writeJSON(w, r, ext)
+}
+
+func (s *registryServer) handleVersions(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ext, found := s.modules[r.PathValue("module")]
+	if !found {
+		writeError(w, http.StatusNotFound, errModuleNotFound)
+
+		return
+	}
+
+	versions := ext.Versions
+	if versions == nil {
+		versions = []string{}
+	}
+
+	writeJSON(w, r, versions)
+}
+
+func (s *registryServer) handleResolve(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names, found := s.catalog[r.PathValue("kind")]
+	if !found {
+		writeError(w, http.StatusNotFound, errUnknownNameKind)
+
+		return
+	}
+
+	ext, found := names[r.PathValue("name")]
+	if !found {
+		writeError(w, http.StatusNotFound, errNameNotFound)
+
+		return
+	}
+
+	writeJSON(w, r, ext)
+}
+
+var (
+	errModuleNotFound  = errors.New("module not found")
+	errNameNotFound    = errors.New("no extension registers the name")
+	errUnknownNameKind = errors.New("unknown name kind")
+)
+
+// writeJSON writes value as the JSON response body.
+func writeJSON(w http.ResponseWriter, r *http.Request, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	writeBody(w, r, body)
+}
+
+// writeBody writes the JSON response body with an ETag computed from the body.
+// If the request has a matching If-None-Match header, 304 Not Modified is answered without body.
+func writeBody(w http.ResponseWriter, r *http.Request, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_, _ = w.Write(body)
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag.
+func etagMatch(header string, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,160 @@
+package cmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func newTestRegistryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := new(registryServer)
+
+	if err := server.update(testFilterRegistry()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(server.handler())
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func getT(t *testing.T, srv *httptest.Server, path string, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, body
+}
+
+func TestRegistryServer(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestRegistryServer(t)
+
+	cases := []struct {
+		path   string
+		status int
+		module string
+	}{
+		{"/extensions/github.com/grafana/xk6-sql", http.StatusOK, "github.com/grafana/xk6-sql"},
+		{"/extensions/github.com/grafana/xk6-missing", http.StatusNotFound, ""},
+		{"/resolve/imports/k6/x/foo", http.StatusOK, "github.com/example/xk6-foo"},
+		{"/resolve/outputs/dashboard", http.StatusOK, "github.com/grafana/xk6-dashboard"},
+		{"/resolve/subcommands/dashboard", http.StatusNotFound, ""},
+		{"/resolve/imports/k6/x/missing", http.StatusNotFound, ""},
+		{"/resolve/modules/k6/x/foo", http.StatusNotFound, ""},
+	}
+
+	for _, c := range cases {
+		resp, body := getT(t, srv, c.path, nil)
+
+		if resp.StatusCode != c.status {
+			t.Errorf("%s: got status %d, want %d", c.path, resp.StatusCode, c.status)
+
+			continue
+		}
+
+		if c.status != http.StatusOK {
+			continue
+		}
+
+		var ext k6registry.Extension
+
+		if err := json.Unmarshal(body, &ext); err != nil {
+			t.Fatal(err)
+		}
+
+		if ext.Module != c.module {
+			t.Errorf("%s: got module %q, want %q", c.path, ext.Module, c.module)
+		}
+	}
+}
+
+func TestRegistryServer_Extensions(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestRegistryServer(t)
+
+	query := url.Values{"filter": {`tier == "official"`}, "select": {"versions"}}
+
+	resp, body := getT(t, srv, "/extensions?"+query.Encode(), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d: %s", resp.StatusCode, body)
+	}
+
+	var registry k6registry.Registry
+
+	if err := json.Unmarshal(body, &registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(registry) != 2 || registry[0].Repo != nil {
+		t.Fatalf("unexpected filtered extensions %s", body)
+	}
+
+	resp, _ = getT(t, srv, "/extensions?filter=tier+%3D%3D", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid filter: got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp, body = getT(t, srv, "/versions/github.com/grafana/xk6-sql", nil)
+	if resp.StatusCode != http.StatusOK || string(body) != `["v1.0.0"]` {
+		t.Fatalf("versions: got status %d, body %s", resp.StatusCode, body)
+	}
+
+	resp, body = getT(t, srv, "/registry.schema.json", nil)
+	if resp.StatusCode != http.StatusOK || string(body) != string(k6registry.Schema) {
+		t.Fatalf("schema: got status %d", resp.StatusCode)
+	}
+}
+
+func TestRegistryServer_ETag(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestRegistryServer(t)
+
+	resp, _ := getT(t, srv, "/registry.json", nil)
+
+	etag := resp.Header.Get("ETag")
+	if len(etag) == 0 {
+		t.Fatal("missing ETag header")
+	}
+
+	resp, body := getT(t, srv, "/registry.json", http.Header{"If-None-Match": {`"other", ` + etag}})
+	if resp.StatusCode != http.StatusNotModified || len(body) != 0 {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusNotModified)
+	}
+
+	resp, _ = getT(t, srv, "/registry.json", http.Header{"If-None-Match": {`"other"`}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
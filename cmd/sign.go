@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	errInvalidKey          = errors.New("invalid key")
+	errInvalidSignature    = errors.New("invalid signature")
+	errSignRequiresOutput  = errors.New("signing requires the --out flag")
+	errSignatureMismatched = errors.New("signature verification failed")
+)
+
+// signatureSuffix is appended to the name of a signed file to get the name of its detached signature.
+const signatureSuffix = ".sig"
+
+// readPrivateKey reads a PEM encoded (PKCS #8) ed25519 private key from filename.
+// Such a key can be generated using: openssl genpkey -algorithm ed25519 -out private.pem.
+func readPrivateKey(filename string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalidKey, filename, err)
+	}
+
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: not an ed25519 private key", errInvalidKey, filename)
+	}
+
+	return private, nil
+}
+
+// readPublicKey reads a PEM encoded (PKIX) ed25519 public key from filename.
+// The public key of a private key can be extracted using: openssl pkey -in private.pem -pubout -out public.pem.
+func readPublicKey(filename string) (ed25519.PublicKey, error) {
+	block, err := readPEM(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalidKey, filename, err)
+	}
+
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: not an ed25519 public key", errInvalidKey, filename)
+	}
+
+	return public, nil
+}
+
+func readPEM(filename string) (*pem.Block, error) {
+	data, err := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s: no PEM data", errInvalidKey, filename)
+	}
+
+	return block, nil
+}
+
+// encodeSignature returns the detached signature file content of data: the base64 encoded signature.
+func encodeSignature(key ed25519.PrivateKey, data []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+}
+
+// verifySignature verifies data using the detached signature file content.
+func verifySignature(key ed25519.PublicKey, data []byte, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errInvalidSignature
+	}
+
+	if !ed25519.Verify(key, data, sig) {
+		return errSignatureMismatched
+	}
+
+	return nil
+}
+
+// writeSigned replaces the content of filename with data like replaceFile does.
+// If key is not nil, the detached signature of data is written next to the file.
+//
+// Both files are written to temporary files first, then renamed one after the other.
+// If the signature cannot be renamed, the previous content of the file is restored,
+// so the published file never mismatches its signature without an error saying so.
+func writeSigned(filename string, data []byte, key ed25519.PrivateKey) (bool, error) {
+	if key == nil {
+		return replaceFile(filename, data)
+	}
+
+	sigfile := filename + signatureSuffix
+	signature := encodeSignature(key, data)
+
+	current, currentErr := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+	if currentErr == nil && bytes.Equal(current, data) {
+		sig, err := os.ReadFile(filepath.Clean(sigfile)) //nolint:forbidigo // CLI tool
+		if err == nil && bytes.Equal(sig, signature) {
+			return false, nil
+		}
+	}
+
+	dataTmp, err := writeTemp(filename, data)
+	if err != nil {
+		return false, err
+	}
+
+	sigTmp, err := writeTemp(sigfile, signature)
+	if err != nil {
+		_ = os.Remove(dataTmp) //nolint:forbidigo // CLI tool
+
+		return false, err
+	}
+
+	if err := os.Rename(dataTmp, filename); err != nil { //nolint:forbidigo // CLI tool
+		_ = os.Remove(dataTmp) //nolint:forbidigo // CLI tool
+		_ = os.Remove(sigTmp)  //nolint:forbidigo // CLI tool
+
+		return false, err
+	}
+
+	err = os.Rename(sigTmp, sigfile) //nolint:forbidigo // CLI tool
+	if err == nil {
+		return true, nil
+	}
+
+	_ = os.Remove(sigTmp) //nolint:forbidigo // CLI tool
+
+	var restoreErr error
+
+	if currentErr == nil {
+		_, restoreErr = replaceFile(filename, current)
+	} else {
+		restoreErr = os.Remove(filename) //nolint:forbidigo // CLI tool
+	}
+
+	if restoreErr != nil {
+		return true, fmt.Errorf("%w: %s does not match its signature, restoring it failed: %w: %w",
+			errSignatureMismatched, filename, restoreErr, err)
+	}
+
+	return false, err
+}
+
+func verifyCmd() *cobra.Command {
+	var key, signature string
+
+	cmd := &cobra.Command{
+		Use:   "verify [flags] file",
+		Short: "Verify the signature of a generated registry file",
+		Long: `Verify the signature of a generated registry file.
+
+The file is verified using its detached signature (the file name with .sig suffix by default) and the PEM encoded ed25519 public key of the key used for signing the output.
+
+The command fails if the signature does not match the file content.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			public, err := readPublicKey(key)
+			if err != nil {
+				return err
+			}
+
+			if len(signature) == 0 {
+				signature = args[0] + signatureSuffix
+			}
+
+			data, err := os.ReadFile(filepath.Clean(args[0])) //nolint:forbidigo // CLI tool
+			if err != nil {
+				return err
+			}
+
+			sig, err := os.ReadFile(filepath.Clean(signature)) //nolint:forbidigo // CLI tool
+			if err != nil {
+				return err
+			}
+
+			if err := verifySignature(public, data, sig); err != nil {
+				return fmt.Errorf("%w: %s", err, args[0])
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s: signature verified\n", args[0])
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&key, "key", "", "PEM encoded ed25519 public key file")
+	cmd.Flags().StringVar(&signature, "signature", "", "detached signature file (default: file name with .sig suffix)")
+
+	_ = cmd.MarkFlagRequired("key")
+
+	return cmd
+}
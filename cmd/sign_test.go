@@ -0,0 +1,162 @@
+package cmd //nolint:testpackage
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestKeysT writes a new ed25519 key pair to private.pem and public.pem in dir.
+func writeTestKeysT(t *testing.T, dir string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFileT(t, dir, "private.pem", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+	writeFileT(t, dir, "public.pem", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})))
+}
+
+func runVerifyT(t *testing.T, args ...string) error {
+	t.Helper()
+
+	cmd := verifyCmd()
+
+	cmd.SetArgs(args)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+
+	return cmd.Execute()
+}
+
+func TestSignVerify(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeTestKeysT(t, dir)
+
+	private, err := readPrivateKey(filepath.Join(dir, "private.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "registry.json")
+
+	if _, err := writeSigned(out, []byte("[]\n"), private); err != nil {
+		t.Fatal(err)
+	}
+
+	public := filepath.Join(dir, "public.pem")
+
+	if err := runVerifyT(t, "--key", public, out); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFileT(t, dir, "registry.json", "[{}]\n")
+
+	if err := runVerifyT(t, "--key", public, out); !errors.Is(err, errSignatureMismatched) {
+		t.Fatalf("tampered file: got error %v, want %v", err, errSignatureMismatched)
+	}
+
+	writeFileT(t, dir, "other.sig", "not a signature")
+
+	err = runVerifyT(t, "--key", public, "--signature", filepath.Join(dir, "other.sig"), out)
+	if !errors.Is(err, errInvalidSignature) {
+		t.Fatalf("invalid signature: got error %v, want %v", err, errInvalidSignature)
+	}
+
+	if err := runVerifyT(t, "--key", filepath.Join(dir, "private.pem"), out); !errors.Is(err, errInvalidKey) {
+		t.Fatalf("private key as public key: got error %v, want %v", err, errInvalidKey)
+	}
+}
+
+func TestWriteSigned_Failure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeTestKeysT(t, dir)
+
+	private, err := readPrivateKey(filepath.Join(dir, "private.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "registry.json")
+
+	if _, err := writeSigned(out, []byte("[]\n"), private); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory in place of the signature makes the signature rename fail
+	sigfile := out + signatureSuffix
+
+	if err := os.Remove(sigfile); err != nil { //nolint:forbidigo // test file in temp dir
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(sigfile, permDir); err != nil { //nolint:forbidigo // test file in temp dir
+		t.Fatal(err)
+	}
+
+	writeFileT(t, sigfile, "keep", "")
+
+	if _, err := writeSigned(out, []byte("[{}]\n"), private); err == nil {
+		t.Fatal("expected error writing signature")
+	}
+
+	data, err := os.ReadFile(out) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "[]\n" {
+		t.Fatalf("got %q, want the previous content restored", data)
+	}
+
+	entries, err := os.ReadDir(dir) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			t.Errorf("temporary file %s left", entry.Name())
+		}
+	}
+}
+
+func TestReadPrivateKey_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeFileT(t, dir, "empty.pem", "")
+	writeFileT(t, dir, "garbage.pem", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("x")})))
+
+	for _, name := range []string{"empty.pem", "garbage.pem"} {
+		if _, err := readPrivateKey(filepath.Join(dir, name)); !errors.Is(err, errInvalidKey) {
+			t.Errorf("%s: got error %v, want %v", name, err, errInvalidKey)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/grafana/k6registry"
@@ -119,11 +121,203 @@ func validateWithSchema(input io.Reader) ([]byte, error) {
 		return yamlRaw, nil
 	}
 
-	var buff strings.Builder
+	problems := make([]validationProblem, 0, len(result.Errors()))
 
 	for _, desc := range result.Errors() {
-		fmt.Fprintf(&buff, " - %s\n", desc.String()) //nolint:gosec // schema validation error output
+		fields := strings.Split(desc.Context().String("\x00"), "\x00")[1:]
+
+		problems = append(problems, validationProblem{fields: fields, Message: desc.Description()})
+	}
+
+	return nil, newValidationError("schema validation failed", yamlRaw, problems)
+}
+
+// validationProblem is a problem found by the validation of the registry source.
+type validationProblem struct {
+	// Path of the offending value, for example "12.imports.0".
+	Path string `json:"path"`
+
+	// Position of the offending value in the source (1-based), zero if unknown.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+
+	Message string `json:"message"`
+
+	fields []string
+}
+
+func (p *validationProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Path, p.Message)
+	}
+
+	return fmt.Sprintf("line %d, column %d: %s: %s", p.Line, p.Column, p.Path, p.Message)
+}
+
+// validationError contains the problems found by a validation step.
+// It wraps errInvalidRegistry.
+type validationError struct {
+	summary  string
+	Problems []validationProblem
+}
+
+// newValidationError returns a validation error with the problems located in the YAML source.
+func newValidationError(summary string, source []byte, problems []validationProblem) *validationError {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(source, &doc); err != nil || len(doc.Content) == 0 {
+		doc.Content = nil
+	}
+
+	for idx := range problems {
+		problem := &problems[idx]
+
+		problem.Path = "(root)"
+		if len(problem.fields) > 0 {
+			problem.Path = strings.Join(problem.fields, ".")
+		}
+
+		if len(doc.Content) > 0 {
+			node := locate(doc.Content[0], problem.fields)
+
+			problem.Line, problem.Column = node.Line, node.Column
+		}
+	}
+
+	return &validationError{summary: summary, Problems: problems}
+}
+
+func (e *validationError) Error() string {
+	var buff strings.Builder
+
+	fmt.Fprintf(&buff, "%s: %s\n", errInvalidRegistry, e.summary)
+
+	for idx := range e.Problems {
+		fmt.Fprintf(&buff, " - %s\n", e.Problems[idx].String())
+	}
+
+	return buff.String()
+}
+
+func (e *validationError) Unwrap() error {
+	return errInvalidRegistry
+}
+
+// locate returns the deepest node of the path found in the YAML node tree.
+func locate(node *yaml.Node, fields []string) *yaml.Node {
+	for _, field := range fields {
+		var next *yaml.Node
+
+		switch node.Kind {
+		case yaml.SequenceNode:
+			if idx, err := strconv.Atoi(field); err == nil && idx >= 0 && idx < len(node.Content) {
+				next = node.Content[idx]
+			}
+		case yaml.MappingNode:
+			for idx := 0; idx+1 < len(node.Content); idx += 2 {
+				if node.Content[idx].Value == field {
+					next = node.Content[idx+1]
+
+					break
+				}
+			}
+		default:
+		}
+
+		if next == nil {
+			break
+		}
+
+		node = next
+	}
+
+	return node
+}
+
+// writeValidationReport writes the problems of the validation error as a JSON array to filename.
+// If err is nil, an empty array is written. If err is not a validation error, the validation
+// result is unknown, so the report is not written.
+func writeValidationReport(filename string, err error) error {
+	problems := []validationProblem{}
+
+	var verr *validationError
+
+	if errors.As(err, &verr) {
+		problems = verr.Problems
+	} else if err != nil {
+		return nil
+	}
+
+	var buff bytes.Buffer
+
+	if err := newJSONEncoder(&buff, false).Encode(problems); err != nil {
+		return err
+	}
+
+	_, err = replaceFile(filename, buff.Bytes())
+
+	return err
+}
+
+// validateSemantics validates the requirements of the registry source that cannot be expressed
+// in the JSON schema: the modules must be unique and an import path, output name or subcommand name
+// must be claimed by only one extension.
+// The problems are reported per entry and located in the YAML source.
+func validateSemantics(registry k6registry.Registry, source []byte) error {
+	var problems []validationProblem
+
+	modules := make(map[string]int, len(registry))
+
+	for idx, ext := range registry {
+		if first, found := modules[ext.Module]; found {
+			problems = append(problems, validationProblem{
+				fields:  []string{strconv.Itoa(idx), "module"},
+				Message: fmt.Sprintf("Duplicate module %s, already defined by entry %d", ext.Module, first),
+			})
+
+			continue
+		}
+
+		modules[ext.Module] = idx
+	}
+
+	claims := []struct {
+		property string
+		names    func(ext *k6registry.Extension) []string
+	}{
+		{"imports", func(ext *k6registry.Extension) []string { return ext.Imports }},
+		{"outputs", func(ext *k6registry.Extension) []string { return ext.Outputs }},
+		{"subcommands", func(ext *k6registry.Extension) []string { return ext.Subcommands }},
+	}
+
+	for _, claim := range claims {
+		owners := make(map[string]int)
+
+		for idx := range registry {
+			for pos, name := range claim.names(&registry[idx]) {
+				first, found := owners[name]
+				if !found {
+					owners[name] = idx
+
+					continue
+				}
+
+				problem := validationProblem{fields: []string{strconv.Itoa(idx), claim.property, strconv.Itoa(pos)}}
+
+				if first == idx {
+					problem.Message = "Duplicate value " + name
+				} else {
+					problem.Message = fmt.Sprintf("%s is already claimed by entry %d (%s)", name, first, registry[first].Module)
+				}
+
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
 	}
 
-	return nil, fmt.Errorf("%w: schema validation failed\n%s", errInvalidRegistry, buff.String())
+	return newValidationError("semantic validation failed", source, problems)
 }
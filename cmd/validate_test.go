@@ -0,0 +1,191 @@
+package cmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func TestValidateSemantics(t *testing.T) {
+	t.Parallel()
+
+	valid := k6registry.Registry{
+		{Module: "example.com/xk6-foo", Imports: []string{"k6/x/foo"}, Subcommands: []string{"foo"}},
+		{Module: "example.com/xk6-bar", Imports: []string{"k6/x/bar"}, Outputs: []string{"foo"}},
+	}
+
+	if err := validateSemantics(valid, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := k6registry.Registry{
+		{Module: "example.com/xk6-foo", Imports: []string{"k6/x/foo"}},
+		{Module: "example.com/xk6-faker", Imports: []string{"k6/x/faker"}, Outputs: []string{"faker"}},
+		{Module: "example.com/xk6-foo"},
+		{Module: "example.com/xk6-fake", Imports: []string{"k6/x/fake", "k6/x/faker"}},
+		{Module: "example.com/xk6-out", Outputs: []string{"out", "out", "faker"}},
+	}
+
+	err := validateSemantics(invalid, nil)
+	if !errors.Is(err, errInvalidRegistry) {
+		t.Fatalf("got error %v, want %v", err, errInvalidRegistry)
+	}
+
+	for _, problem := range []string{
+		"2.module: Duplicate module example.com/xk6-foo, already defined by entry 0",
+		"3.imports.1: k6/x/faker is already claimed by entry 1 (example.com/xk6-faker)",
+		"4.outputs.1: Duplicate value out",
+		"4.outputs.2: faker is already claimed by entry 1 (example.com/xk6-faker)",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem %q not reported: %v", problem, err)
+		}
+	}
+}
+
+func TestLoad_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	ctx := newTestLoadContext(t, newTestProvider())
+
+	src := "- module: example.com/xk6-foo\n  imports: [k6/x/foo]\n- module: example.com/xk6-bar\n  imports: [k6/x/foo]\n"
+
+	_, err := load(ctx, strings.NewReader(src), loadOptions{})
+	if !errors.Is(err, errInvalidRegistry) {
+		t.Fatalf("got error %v, want %v", err, errInvalidRegistry)
+	}
+}
+
+func TestValidationError_Position(t *testing.T) {
+	t.Parallel()
+
+	src := `- module: example.com/xk6-foo
+  imports: [k6/x/foo]
+- module: example.com/xk6-bar
+  imports:
+    - k6/x/bar
+    - k6/x/foo
+- module: example.com/xk6-foo
+`
+
+	_, err := loadSource(strings.NewReader(src))
+
+	var verr *validationError
+
+	if !errors.As(err, &verr) {
+		t.Fatalf("got error %v, want validation error", err)
+	}
+
+	want := []validationProblem{
+		{Path: "2.module", Line: 7, Column: 11},
+		{Path: "1.imports.1", Line: 6, Column: 7},
+	}
+
+	if len(verr.Problems) != len(want) {
+		t.Fatalf("got problems %v, want %d problems", verr.Problems, len(want))
+	}
+
+	for idx, problem := range verr.Problems {
+		if problem.Path != want[idx].Path || problem.Line != want[idx].Line || problem.Column != want[idx].Column {
+			t.Errorf("got problem %s, want %s at line %d, column %d",
+				problem.String(), want[idx].Path, want[idx].Line, want[idx].Column)
+		}
+	}
+
+	if !strings.Contains(err.Error(), "line 6, column 7: 1.imports.1: k6/x/foo is already claimed by entry 0") {
+		t.Errorf("unexpected error message %v", err)
+	}
+}
+
+func TestValidationError_Schema(t *testing.T) {
+	t.Parallel()
+
+	src := `- module: example.com/xk6-foo
+- module: example.com/xk6-bar
+  tier: gold
+- imports: [k6/x/baz]
+`
+
+	_, err := validateWithSchema(strings.NewReader(src))
+
+	var verr *validationError
+
+	if !errors.As(err, &verr) {
+		t.Fatalf("got error %v, want validation error", err)
+	}
+
+	lines := map[string]int{}
+
+	for _, problem := range verr.Problems {
+		lines[problem.Path] = problem.Line
+	}
+
+	if lines["1.tier"] != 3 {
+		t.Errorf("tier: got line %d, want 3 (%v)", lines["1.tier"], verr.Problems)
+	}
+
+	if lines["2"] != 4 {
+		t.Errorf("missing module: got line %d, want 4 (%v)", lines["2"], verr.Problems)
+	}
+}
+
+func TestWriteValidationReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "report.json")
+
+	_, err := loadSource(strings.NewReader("- module: example.com/xk6-foo\n- module: example.com/xk6-foo\n"))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	if err := writeValidationReport(filename, err); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var problems []validationProblem
+
+	if err := json.Unmarshal(data, &problems); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 1 || problems[0].Path != "1.module" || problems[0].Line != 2 || problems[0].Column != 11 {
+		t.Fatalf("unexpected report %s", data)
+	}
+
+	if err := writeValidationReport(filename, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = os.ReadFile(filename) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(string(data)) != "[]" {
+		t.Fatalf("got report %s, want empty array", data)
+	}
+
+	// other errors leave the report unwritten
+	other := filepath.Join(dir, "other.json")
+
+	if err := writeValidationReport(other, errUnsupportedModule); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(other); !errors.Is(err, fs.ErrNotExist) { //nolint:forbidigo // test file in temp dir
+		t.Fatalf("got error %v, want report not written", err)
+	}
+}
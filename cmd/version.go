@@ -4,6 +4,7 @@ import (
 	"sort"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/grafana/k6registry"
 )
 
 func tagsToVersions(tags []string) []string {
@@ -63,3 +64,72 @@ func sortVersions(versions []string) error {
 
 	return nil
 }
+
+// limitVersionsPerMajor keeps at most limit versions of each major version.
+// The versions must be sorted in descending order, so the latest ones are kept.
+// A limit less than 1 means no limit.
+func limitVersionsPerMajor(versions []string, limit int) []string {
+	if limit < 1 {
+		return versions
+	}
+
+	counts := make(map[uint64]int)
+	kept := make([]string, 0, len(versions))
+
+	for _, source := range versions {
+		parsed, err := semver.NewVersion(source)
+		if err != nil {
+			continue
+		}
+
+		counts[parsed.Major()]++
+
+		if counts[parsed.Major()] <= limit {
+			kept = append(kept, source)
+		}
+	}
+
+	return kept
+}
+
+// filterPrereleases removes the prerelease versions according to policy.
+// With the latest-only policy, only the newest prerelease is kept, if it is newer than the latest stable version.
+func filterPrereleases(versions []string, policy k6registry.Prerelease) []string {
+	if policy != k6registry.PrereleaseExclude && policy != k6registry.PrereleaseLatestOnly {
+		return versions
+	}
+
+	var latest, newest *semver.Version
+
+	newestIdx := -1
+
+	for idx, source := range versions {
+		version, err := semver.NewVersion(source)
+		if err != nil {
+			continue
+		}
+
+		if len(version.Prerelease()) == 0 {
+			if latest == nil || version.GreaterThan(latest) {
+				latest = version
+			}
+		} else if newest == nil || version.GreaterThan(newest) {
+			newest, newestIdx = version, idx
+		}
+	}
+
+	if policy != k6registry.PrereleaseLatestOnly || (latest != nil && newest != nil && !newest.GreaterThan(latest)) {
+		newestIdx = -1
+	}
+
+	kept := make([]string, 0, len(versions))
+
+	for idx, source := range versions {
+		version, err := semver.NewVersion(source)
+		if err != nil || len(version.Prerelease()) == 0 || idx == newestIdx {
+			kept = append(kept, source)
+		}
+	}
+
+	return kept
+}
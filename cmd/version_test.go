@@ -0,0 +1,82 @@
+package cmd //nolint:testpackage
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/grafana/k6registry"
+)
+
+func TestLimitVersionsPerMajor(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"v2.1.0", "v2.0.1", "v2.0.0", "v1.2.0", "v1.1.0", "v1.0.0", "v0.9.0"}
+
+	cases := []struct {
+		limit int
+		want  []string
+	}{
+		{0, versions},
+		{1, []string{"v2.1.0", "v1.2.0", "v0.9.0"}},
+		{2, []string{"v2.1.0", "v2.0.1", "v1.2.0", "v1.1.0", "v0.9.0"}},
+		{10, versions},
+	}
+
+	for _, c := range cases {
+		if got := limitVersionsPerMajor(slices.Clone(versions), c.limit); !slices.Equal(got, c.want) {
+			t.Errorf("limitVersionsPerMajor(%d) = %v, want %v", c.limit, got, c.want)
+		}
+	}
+}
+
+func TestFilterPrereleases(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		versions []string
+		policy   k6registry.Prerelease
+		want     []string
+	}{
+		{
+			[]string{"v0.8.0-alpha.1", "v0.7.3", "v0.7.3-alpha.1", "v0.7.0"},
+			k6registry.PrereleaseInclude,
+			[]string{"v0.8.0-alpha.1", "v0.7.3", "v0.7.3-alpha.1", "v0.7.0"},
+		},
+		{
+			[]string{"v0.8.0-alpha.1", "v0.7.3", "v0.7.3-alpha.1", "v0.7.0"},
+			k6registry.PrereleaseExclude,
+			[]string{"v0.7.3", "v0.7.0"},
+		},
+		{
+			[]string{"v0.8.0-alpha.1", "v0.7.3", "v0.7.3-alpha.1", "v0.7.0"},
+			k6registry.PrereleaseLatestOnly,
+			[]string{"v0.8.0-alpha.1", "v0.7.3", "v0.7.0"},
+		},
+		{
+			[]string{"v0.1.0-rc.2", "v0.1.0-rc.1"},
+			k6registry.PrereleaseLatestOnly,
+			[]string{"v0.1.0-rc.2"},
+		},
+		{
+			[]string{"v1.0.0-rc.3", "v1.0.0-rc.2", "v1.0.0-rc.1", "v0.9.0"},
+			k6registry.PrereleaseLatestOnly,
+			[]string{"v1.0.0-rc.3", "v0.9.0"},
+		},
+		{
+			[]string{"v1.0.0", "v1.0.0-rc.1", "v0.9.0"},
+			k6registry.PrereleaseLatestOnly,
+			[]string{"v1.0.0", "v0.9.0"},
+		},
+		{
+			[]string{"v0.1.0-rc.1"},
+			"",
+			[]string{"v0.1.0-rc.1"},
+		},
+	}
+
+	for _, c := range cases {
+		if got := filterPrereleases(c.versions, c.policy); !slices.Equal(got, c.want) {
+			t.Errorf("filterPrereleases(%v, %q) = %v, want %v", c.versions, c.policy, got, c.want)
+		}
+	}
+}
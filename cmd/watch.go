@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+var errWatchRequiresFiles = errors.New("watch mode requires a source file and the --out flag")
+
+const (
+	defaultWatchInterval = time.Hour
+	watchPollInterval    = time.Second
+)
+
+// watch generates the registry from the source file, then generates it again whenever the source file
+// changes or the watch interval elapses, until ctx is done or the process is interrupted.
+//
+// The previous result of the extensions whose source definition has not changed is passed to each generation,
+// so only the modified repositories are processed again.
+// The output file is replaced atomically and only if the output has changed.
+// A failed regeneration is logged and the output is left unchanged.
+func watch(
+	ctx context.Context,
+	filename string,
+	opts *options,
+	emit func(registry k6registry.Registry, output io.Writer) error,
+) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the generation metadata (e.g. the generation time of the envelope) alone is not a change
+	var last []byte
+
+	// the result and the source definitions of the last successful generation, by module path
+	var (
+		generated   map[string]*k6registry.Extension
+		definitions map[string][]byte
+	)
+
+	regenerate := func(source []byte) error {
+		current := sourceDefinitions(source)
+
+		// the first generation uses the registry passed by the --previous flag, if any
+		if generated != nil {
+			opts.loadOptions.previous = unchangedExtensions(generated, definitions, current)
+		}
+
+		registry, err := generate(ctx, bytes.NewReader(source), opts)
+		if err != nil {
+			return err
+		}
+
+		generated, definitions = extensionsByModule(registry), current
+
+		fingerprint, err := json.Marshal(registry)
+		if err != nil {
+			return err
+		}
+
+		fingerprint = append(fingerprint, opts.sourceSHA256...)
+
+		if bytes.Equal(fingerprint, last) {
+			slog.Debug("Registry unchanged", "file", opts.out)
+
+			return nil
+		}
+
+		var buff bytes.Buffer
+
+		if err := emit(registry, &buff); err != nil {
+			return err
+		}
+
+		if !opts.quiet {
+			changed, err := writeSigned(opts.out, buff.Bytes(), opts.signer)
+			if err != nil {
+				return err
+			}
+
+			if changed {
+				slog.Info("Output updated", "file", opts.out, "extensions", len(registry))
+			} else {
+				slog.Debug("Output unchanged", "file", opts.out)
+			}
+		}
+
+		last = fingerprint
+
+		return nil
+	}
+
+	source, err := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+	if err != nil {
+		return err
+	}
+
+	if err := regenerate(source); err != nil {
+		return err
+	}
+
+	var tick <-chan time.Time
+
+	if opts.interval > 0 {
+		ticker := time.NewTicker(opts.interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	poll := time.NewTicker(watchPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick:
+			slog.Debug("Watch interval elapsed")
+		case <-poll.C:
+			current, err := os.ReadFile(filepath.Clean(filename)) //nolint:forbidigo // CLI tool
+			if err != nil {
+				slog.Debug("Failed to read source", "file", filename, "error", err)
+
+				continue
+			}
+
+			if bytes.Equal(current, source) {
+				continue
+			}
+
+			source = current
+
+			slog.Info("Source changed", "file", filename)
+		}
+
+		if err := regenerate(source); err != nil {
+			slog.Error("Regeneration failed, output left unchanged", "error", err)
+		}
+	}
+}
+
+// sourceDefinitions returns the JSON encoded source definitions of the extensions by module path.
+// An invalid source has no definitions, the problems are reported by the generation.
+func sourceDefinitions(source []byte) map[string][]byte {
+	registry, err := loadSource(bytes.NewReader(source))
+	if err != nil {
+		return nil
+	}
+
+	definitions := make(map[string][]byte, len(registry))
+
+	for _, ext := range registry {
+		data, err := json.Marshal(ext)
+		if err != nil {
+			return nil
+		}
+
+		definitions[ext.Module] = data
+	}
+
+	return definitions
+}
+
+// unchangedExtensions returns the generated extensions whose source definition is the same
+// in the previous and in the current source.
+func unchangedExtensions(
+	generated map[string]*k6registry.Extension,
+	previous map[string][]byte,
+	current map[string][]byte,
+) map[string]*k6registry.Extension {
+	unchanged := make(map[string]*k6registry.Extension, len(generated))
+
+	for module, ext := range generated {
+		if def, found := current[module]; found && bytes.Equal(def, previous[module]) {
+			unchanged[module] = ext
+		}
+	}
+
+	return unchanged
+}
@@ -0,0 +1,146 @@
+package cmd //nolint:testpackage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/k6registry"
+)
+
+func waitFileT(t *testing.T, filename string, cond func(content string) bool) string {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(filename) //nolint:forbidigo // test file in temp dir
+		if err == nil && cond(string(data)) {
+			return string(data)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatalf("timeout waiting for %s", filename)
+
+	return ""
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider()
+	ctx, cancel := context.WithCancel(newTestLoadContext(t, provider,
+		&fakeProvider{prefix: k6Module, repos: provider.repos, tags: provider.tags}))
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "registry.yaml")
+	out := filepath.Join(dir, "registry.json")
+
+	writeFileT(t, dir, "registry.yaml", "- module: example.com/xk6-foo\n")
+	opts := &options{out: out}
+
+	done := make(chan error)
+
+	go func() {
+		done <- watch(ctx, source, opts, func(registry k6registry.Registry, output io.Writer) error {
+			return encodeJSON(registry, output, false)
+		})
+	}()
+
+	waitFileT(t, out, func(content string) bool { return strings.Contains(content, "example.com/xk6-foo") })
+
+	stat, err := os.Stat(out) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an invalid source is reported and the output is left unchanged
+	writeFileT(t, dir, "registry.yaml", "- module: example.com/xk6-foo\n- module: example.com/xk6-foo\n")
+
+	time.Sleep(2 * watchPollInterval)
+
+	unchanged, err := os.Stat(out) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !unchanged.ModTime().Equal(stat.ModTime()) {
+		t.Fatal("output replaced after failed regeneration")
+	}
+
+	writeFileT(t, dir, "registry.yaml", "- module: example.com/xk6-foo\n- module: example.com/xk6-bar\n")
+
+	waitFileT(t, out, func(content string) bool { return strings.Contains(content, "example.com/xk6-bar") })
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnchangedExtensions(t *testing.T) {
+	t.Parallel()
+
+	before := sourceDefinitions([]byte("- module: example.com/xk6-foo\n- module: example.com/xk6-bar\n"))
+	after := sourceDefinitions([]byte("- module: example.com/xk6-foo\n- module: example.com/xk6-bar\n  tier: official\n"))
+
+	generated := map[string]*k6registry.Extension{
+		"example.com/xk6-foo": {Module: "example.com/xk6-foo"},
+		"example.com/xk6-bar": {Module: "example.com/xk6-bar"},
+		k6Module:              {Module: k6Module},
+	}
+
+	unchanged := unchangedExtensions(generated, before, after)
+
+	if len(unchanged) != 2 || unchanged["example.com/xk6-foo"] == nil || unchanged[k6Module] == nil {
+		t.Fatalf("got %v, want the extensions with unchanged source definition", unchanged)
+	}
+
+	if unchanged := unchangedExtensions(generated, before, sourceDefinitions([]byte("invalid"))); len(unchanged) != 0 {
+		t.Fatalf("got %v, want none for invalid source", unchanged)
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "out.json")
+
+	for _, c := range []struct {
+		data    string
+		changed bool
+	}{
+		{"[]\n", true},
+		{"[]\n", false},
+		{"[{}]\n", true},
+	} {
+		changed, err := replaceFile(filename, []byte(c.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if changed != c.changed {
+			t.Errorf("%q: got changed %t, want %t", c.data, changed, c.changed)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(filename)) //nolint:forbidigo // test file in temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d files, want only the output file", len(entries))
+	}
+
+	if _, err := replaceFile(filepath.Join(filename, "missing", "out.json"), []byte("[]")); err == nil {
+		t.Fatal("expected error writing to missing directory")
+	}
+}
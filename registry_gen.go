@@ -4,6 +4,14 @@ package k6registry
 
 // The result of the extension's k6 compliance checks.
 type Compliance struct {
+	// A list of the enabled compliance check IDs.
+	//
+	// The `checks` property contains the IDs of the compliance checks that were
+	// enabled (e.g. using the `--lint-checks` flag of the generator). It is missing if
+	// all compliance checks were enabled.
+	//
+	Checks []string `json:"checks,omitempty" yaml:"checks,omitempty" mapstructure:"checks,omitempty"`
+
 	// A list of compliance check IDs that failed.
 	//
 	// The `issues`` property is primarily used for debugging. It contains the
@@ -12,6 +20,51 @@ type Compliance struct {
 	Issues []string `json:"issues,omitempty" yaml:"issues,omitempty" mapstructure:"issues,omitempty"`
 }
 
+// k6 Extension Registry envelope.
+//
+// The optional envelope wraps the generated registry with the metadata of the
+// generation, so consumers can tell when, by which generator version and from
+// which source the registry was generated.
+type Envelope struct {
+	// Digests of the extensions.
+	//
+	// The digest of each extension of the registry, keyed by the module path. The
+	// digest is the hex encoded SHA-256 digest of the compact JSON encoding of the
+	// extension, prefixed with `sha256:`.
+	//
+	Digests EnvelopeDigests `json:"digests,omitempty" yaml:"digests,omitempty" mapstructure:"digests,omitempty"`
+
+	// Generation time.
+	//
+	// The time of the generation in RFC 3339 format, in UTC.
+	//
+	GeneratedAt string `json:"generated_at,omitempty" yaml:"generated_at,omitempty" mapstructure:"generated_at,omitempty"`
+
+	// Version of the generator.
+	//
+	// The version of the k6registry tool that generated the registry.
+	//
+	GeneratorVersion string `json:"generator_version,omitempty" yaml:"generator_version,omitempty" mapstructure:"generator_version,omitempty"`
+
+	// The generated registry.
+	//
+	Registry Registry `json:"registry" yaml:"registry" mapstructure:"registry"`
+
+	// SHA-256 digest of the registry source.
+	//
+	// The hex encoded SHA-256 digest of the registry source the registry was generated
+	// from.
+	//
+	SourceSha256 string `json:"source_sha256,omitempty" yaml:"source_sha256,omitempty" mapstructure:"source_sha256,omitempty"`
+}
+
+// Digests of the extensions.
+//
+// The digest of each extension of the registry, keyed by the module path. The
+// digest is the hex encoded SHA-256 digest of the compact JSON encoding of the
+// extension, prefixed with `sha256:`.
+type EnvelopeDigests map[string]string
+
 // Properties of the registered k6 extension.
 //
 // Only those properties of the extensions are registered, which either cannot be
@@ -84,6 +137,20 @@ type Extension struct {
 	//
 	Outputs []string `json:"outputs,omitempty" yaml:"outputs,omitempty" mapstructure:"outputs,omitempty"`
 
+	// Prerelease version policy.
+	//
+	// Possible values:
+	//
+	//   - include: Prerelease versions are kept.
+	//   - exclude: Prerelease versions are removed.
+	//   - latest-only: Only the newest prerelease version is kept, and only if it is
+	// newer than the latest stable version.
+	//
+	// If it is missing from the registry source, the default policy of the generation
+	// is used, which is `include` by default.
+	//
+	Prerelease Prerelease `json:"prerelease,omitempty" yaml:"prerelease,omitempty" mapstructure:"prerelease,omitempty"`
+
 	// Repository metadata.
 	//
 	// Metadata provided by the extension's git repository manager. Repository
@@ -121,6 +188,20 @@ type Extension struct {
 	//
 	Tier Tier `json:"tier,omitempty" yaml:"tier,omitempty" mapstructure:"tier,omitempty"`
 
+	// Source of the automatically detected versions.
+	//
+	// Possible values:
+	//
+	//   - tags: Versions are detected from the repository tags.
+	//   - releases: Versions are detected from the repository releases.
+	//
+	// If it is missing from the registry source, the default source of the generation
+	// is used. Without a default source, GitLab releases and the tags of other
+	// repository managers are used. If the default source is releases, the tags are
+	// used for the repositories without releases (Bitbucket and clone URL).
+	//
+	VersionSource VersionSource `json:"version_source,omitempty" yaml:"version_source,omitempty" mapstructure:"version_source,omitempty"`
+
 	// List of supported versions.
 	//
 	// Versions are tags whose format meets the requirements of semantic versioning.
@@ -133,6 +214,12 @@ type Extension struct {
 // The result of the extension's k6 compliance checks.
 type ExtensionCompliance map[string]Compliance
 
+type Prerelease string
+
+const PrereleaseExclude Prerelease = "exclude"
+const PrereleaseInclude Prerelease = "include"
+const PrereleaseLatestOnly Prerelease = "latest-only"
+
 // k6 Extension Registry.
 //
 // The k6 extension registry contains the most important properties of registered
@@ -228,3 +315,8 @@ type Tier string
 
 const TierCommunity Tier = "community"
 const TierOfficial Tier = "official"
+
+type VersionSource string
+
+const VersionSourceReleases VersionSource = "releases"
+const VersionSourceTags VersionSource = "tags"